@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/iomallach/tmux-session-manager/internal/tsm"
+)
+
+// Exit codes let scripts tell apart why a command failed without parsing
+// its output.
+const (
+	exitError             = 1
+	exitUsage             = 2
+	exitSessionNotFound   = 3
+	exitDuplicateSession  = 4
+	exitNoServer          = 5
+	exitPermissionDenied  = 6
+	exitProtectedSession  = 7
+	exitInvalidName       = 8
+	exitBadConfig         = 9
+	exitNoPin             = 10
+	exitNoTaggedSessions  = 11
+	exitNoPreviousSession = 12
+)
+
+func exitCode(err error) int {
+	var configErr *tsm.ConfigError
+	switch {
+	case errors.Is(err, tsm.ErrSessionNotFound):
+		return exitSessionNotFound
+	case errors.Is(err, tsm.ErrDuplicateSession):
+		return exitDuplicateSession
+	case errors.Is(err, tsm.ErrNoServer):
+		return exitNoServer
+	case errors.Is(err, tsm.ErrPermissionDenied):
+		return exitPermissionDenied
+	case errors.Is(err, tsm.ErrProtectedSession):
+		return exitProtectedSession
+	case errors.Is(err, tsm.ErrEmptyName), errors.Is(err, tsm.ErrIllegalName), errors.Is(err, tsm.ErrNameTooLong):
+		return exitInvalidName
+	case errors.As(err, &configErr):
+		return exitBadConfig
+	case errors.Is(err, tsm.ErrNoPin):
+		return exitNoPin
+	case errors.Is(err, tsm.ErrNoTaggedSessions):
+		return exitNoTaggedSessions
+	case errors.Is(err, tsm.ErrNoPreviousSession):
+		return exitNoPreviousSession
+	}
+	return exitError
+}
+
+// fail prints err and exits with the code that matches it.
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(exitCode(err))
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"github.com/iomallach/tmux-session-manager/internal/tsm"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&config.HistoryPath, "history-file", config.HistoryPath, "where switches are recorded to rank sessions by frecency")
+	historyCmd.AddCommand(&historyRecordCmd)
+	rootCmd.AddCommand(&historyCmd)
+}
+
+var historyCmd = cobra.Command{
+	Use:   "history",
+	Short: "Keep the history sessions are ranked by",
+}
+
+var historyRecordCmd = cobra.Command{
+	Use:   "record <session>",
+	Short: "Record a switch to a session made outside of tsm",
+	Long: `Record a switch to a session made outside of tsm.
+
+Switches made through tsm are recorded already. To count the ones made with
+tmux itself too, add a hook to tmux.conf:
+
+  set-hook -g client-session-changed 'run-shell -b "tsm history record #{q:session_name}"'`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := tsm.RecordSwitch(config.HistoryPath, "", args[0]); err != nil {
+			fail(err)
+		}
+	},
+}
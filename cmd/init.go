@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/iomallach/tmux-session-manager/internal/tsm"
+	"github.com/spf13/cobra"
+)
+
+var bindings = tsm.DefaultTmuxBindings()
+
+var tmuxConfPath = tsm.DefaultTmuxConfPath()
+
+var appendBlock, removeBlock bool
+
+func init() {
+	initTmuxCmd.Flags().StringVar(&bindings.Popup, "popup-key", bindings.Popup, `key after the prefix that opens tsm in a popup, "" for none`)
+	initTmuxCmd.Flags().StringVar(&bindings.Last, "last-key", bindings.Last, `key after the prefix that switches to the last session, "" for none`)
+	initTmuxCmd.Flags().StringVar(&bindings.Save, "save-key", bindings.Save, `key after the prefix that saves a snapshot, "" for none`)
+	initTmuxCmd.Flags().StringVar(&bindings.Jump, "jump-key", bindings.Jump, `key after the prefix that is followed by 1 to 9 to jump to a pin, "" for none`)
+	initTmuxCmd.Flags().StringVar(&bindings.Command, "command", bindings.Command, "how tmux runs tsm")
+	initTmuxCmd.Flags().StringVar(&tmuxConfPath, "file", tmuxConfPath, "tmux.conf to add the block to or remove it from")
+	initTmuxCmd.Flags().BoolVar(&appendBlock, "append", false, "add the block to tmux.conf, or update the one there")
+	initTmuxCmd.Flags().BoolVar(&removeBlock, "remove", false, "take the block out of tmux.conf")
+	initTmuxCmd.MarkFlagsMutuallyExclusive("append", "remove")
+	initCmd.AddCommand(&initTmuxCmd)
+	rootCmd.AddCommand(&initCmd)
+}
+
+var initCmd = cobra.Command{
+	Use:   "init",
+	Short: "Set up the tools tsm works with",
+}
+
+var initTmuxCmd = cobra.Command{
+	Use:   "tmux",
+	Short: "Print the tmux.conf key bindings for tsm, or add them to tmux.conf",
+	Long: `Print the tmux.conf key bindings for tsm, or add them to tmux.conf.
+
+The bindings open tsm in a popup, switch to the session used before the
+current one, save a snapshot and jump to the pinned sessions: the jump key
+followed by the digit of a slot. They come in a marked block that --append
+adds to tmux.conf, or updates when it is there already, and --remove takes
+out again. Leave a binding out by giving its key as "".`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		block := tsm.TmuxConfBlock(bindings)
+		switch {
+		case appendBlock:
+			if err := tsm.InstallTmuxConfBlock(tmuxConfPath, block); err != nil {
+				fail(err)
+			}
+			fmt.Printf("Updated %s, reload it with: tmux source-file %s\n", tmuxConfPath, tmuxConfPath)
+		case removeBlock:
+			if err := tsm.RemoveTmuxConfBlock(tmuxConfPath); err != nil {
+				fail(err)
+			}
+			fmt.Printf("Removed the bindings from %s, they stay bound until tmux restarts\n", tmuxConfPath)
+		default:
+			fmt.Print(block)
+		}
+	},
+}
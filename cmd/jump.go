@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/iomallach/tmux-session-manager/internal/tsm"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&config.PinsPath, "pins-file", config.PinsPath, "where pinned sessions are kept")
+	rootCmd.AddCommand(&jumpCmd)
+}
+
+var jumpCmd = cobra.Command{
+	Use:   "jump <slot>",
+	Short: "Switch to the session pinned to a slot, starting it when it is not running",
+	Long: `Switch to the session pinned to a slot, starting it when it is not running.
+
+tsm init tmux binds the slots to reach pinned sessions without opening tsm,
+prefix j 1 jumps to the first one.`,
+	Args: cobra.MatchAll(cobra.ExactArgs(1), func(cmd *cobra.Command, args []string) error {
+		if slot, err := strconv.Atoi(args[0]); err != nil || slot < 1 || slot > tsm.PinSlots {
+			return fmt.Errorf("slot must be a number from 1 to %d, got %q", tsm.PinSlots, args[0])
+		}
+		return nil
+	}),
+	Run: func(cmd *cobra.Command, args []string) {
+		slot, _ := strconv.Atoi(args[0])
+		pins, err := tsm.LoadPins(config.PinsPath)
+		if err != nil {
+			fail(err)
+		}
+		tmux := newTmux()
+		if err := tsm.JumpToPin(tmux, pins, slot); err != nil {
+			fail(err)
+		}
+		if err := tmux.Attach(); err != nil {
+			fail(err)
+		}
+	},
+}
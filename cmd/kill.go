@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/iomallach/tmux-session-manager/internal/tsm"
+
+	"github.com/spf13/cobra"
+)
+
+var killTags []string
+
+func init() {
+	killCmd.Flags().StringSliceVarP(&killTags, "tag", "t", nil, "kill every session with this tag too, can be repeated")
+	rootCmd.AddCommand(&killCmd)
+}
+
+var killCmd = cobra.Command{
+	Use:   "kill <name...>",
+	Short: "Kill sessions, except the protected ones, keeping them in the trash",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(killTags) > 0 {
+			return nil
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		// Keep going past sessions that cannot be killed and exit with the
+		// code of the first failure.
+		tmux := newTmux()
+		if len(killTags) > 0 {
+			sessions, err := tmux.TmuxListSessions()
+			if err != nil {
+				fail(err)
+			}
+			tagged := tsm.TaggedSessions(sessions, killTags)
+			if len(tagged) == 0 {
+				// Nothing is killed, and nothing written to the trash.
+				fail(fmt.Errorf("%w %s", tsm.ErrNoTaggedSessions, strings.Join(killTags, ",")))
+			}
+			for _, session := range tagged {
+				if !slices.Contains(args, session.Name) {
+					args = append(args, session.Name)
+				}
+			}
+		}
+		trash, err := tsm.LoadTrash(config.TrashPath)
+		if err != nil {
+			fail(err)
+		}
+		saved, err := tsm.TakeSnapshot(tmux, false, args...)
+		if err != nil {
+			fail(err)
+		}
+
+		code := 0
+		var killed []tsm.SnapshotSession
+		for _, name := range args {
+			err := fmt.Errorf("%s: %w", name, tsm.ErrProtectedSession)
+			if !config.Protected(name) {
+				err = tmux.TmuxKillSession(name)
+			}
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				if code == 0 {
+					code = exitCode(err)
+				}
+				continue
+			}
+			if i := slices.IndexFunc(saved.Sessions, func(s tsm.SnapshotSession) bool { return s.Name == name }); i >= 0 {
+				killed = append(killed, saved.Sessions[i])
+			}
+		}
+
+		trash.Prune(config.TrashRetention, time.Now())
+		trash.Add(killed, time.Now())
+		if err := tsm.SaveTrash(config.TrashPath, trash); err != nil {
+			fail(err)
+		}
+		os.Exit(code)
+	},
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"github.com/iomallach/tmux-session-manager/internal/tsm"
+	"github.com/spf13/cobra"
+)
+
+var switchAfterApply bool
+
+func init() {
+	layoutApplyCmd.Flags().BoolVarP(&switchAfterApply, "switch", "s", false, "switch to the session once it is built")
+	layoutCmd.AddCommand(&layoutApplyCmd)
+	rootCmd.AddCommand(&layoutCmd)
+}
+
+var layoutCmd = cobra.Command{
+	Use:   "layout",
+	Short: "Build sessions from layout files",
+}
+
+var layoutApplyCmd = cobra.Command{
+	Use:   "apply [file]",
+	Short: "Create the session described by a layout file, " + tsm.LayoutFileName + " by default",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path := tsm.LayoutFileName
+		if len(args) > 0 {
+			path = args[0]
+		}
+		layout, err := tsm.LoadLayout(path)
+		if err != nil {
+			fail(err)
+		}
+
+		tmux := newTmux()
+		if err := tsm.ApplyLayout(tmux, layout); err != nil {
+			fail(err)
+		}
+		if switchAfterApply {
+			if err := tmux.TmuxSwitchSession(layout.Name); err != nil {
+				fail(err)
+			}
+			if err := tmux.Attach(); err != nil {
+				fail(err)
+			}
+		}
+	},
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/iomallach/tmux-session-manager/internal/tsm"
+	"github.com/spf13/cobra"
+)
+
+var lsOutput = outputTable
+
+var lsTags []string
+
+func init() {
+	lsCmd.Flags().VarP(&lsOutput, "output", "o", "output format")
+	lsCmd.Flags().StringSliceVarP(&lsTags, "tag", "t", nil, "only list sessions with this tag, can be repeated")
+	rootCmd.AddCommand(&lsCmd)
+}
+
+var lsCmd = cobra.Command{
+	Use:   "ls",
+	Short: "List sessions",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		tmux := newTmux()
+		sessions, err := tmux.TmuxListSessions()
+		if err != nil {
+			fail(err)
+		}
+		history, err := tsm.LoadHistory(config.HistoryPath)
+		if err != nil {
+			fail(err)
+		}
+		current, err := tmux.TmuxCurrentSession()
+		if err != nil {
+			fail(err)
+		}
+		if len(lsTags) > 0 {
+			sessions = tsm.TaggedSessions(sessions, lsTags)
+		}
+		tsm.SortSessions(sessions, config.SortOrder, history, current)
+		if err := writeSessions(os.Stdout, lsOutput, sessions); err != nil {
+			fail(err)
+		}
+	},
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/iomallach/tmux-session-manager/internal/tsm"
+	"github.com/spf13/cobra"
+)
+
+var (
+	newSessionDir    string
+	newSessionAttach bool
+)
+
+func init() {
+	newCmd.Flags().StringVarP(&newSessionDir, "dir", "c", "", "directory to start the session in, the current one by default")
+	newCmd.Flags().BoolVarP(&newSessionAttach, "attach", "a", false, "switch to the session once it is created")
+	rootCmd.AddCommand(&newCmd)
+}
+
+var newCmd = cobra.Command{
+	Use:   "new <name> [command...]",
+	Short: "Create a detached session, running command instead of a shell if given",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		tmux := newTmux()
+		name, err := checkName(tmux, args[0], "")
+		if err != nil {
+			fail(err)
+		}
+		opts := tsm.CreateSessionOptions{Name: name, Dir: newSessionDir, Command: strings.Join(args[1:], " ")}
+		if err := tmux.TmuxCreateSession(opts); err != nil {
+			fail(err)
+		}
+		if !newSessionAttach {
+			return
+		}
+		if err := tmux.TmuxSwitchSession(name); err != nil {
+			fail(err)
+		}
+		if err := tmux.Attach(); err != nil {
+			fail(err)
+		}
+	},
+}
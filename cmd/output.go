@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/iomallach/tmux-session-manager/internal/tsm"
+)
+
+// outputFormat is the value of --output on listing commands.
+type outputFormat string
+
+const (
+	outputTable outputFormat = "table"
+	outputJSON  outputFormat = "json"
+	outputPlain outputFormat = "plain"
+)
+
+var outputFormats = []outputFormat{outputTable, outputJSON, outputPlain}
+
+func (o *outputFormat) String() string {
+	return string(*o)
+}
+
+func (o *outputFormat) Set(value string) error {
+	for _, format := range outputFormats {
+		if string(format) == value {
+			*o = format
+			return nil
+		}
+	}
+	return fmt.Errorf("must be one of %s", o.Type())
+}
+
+func (o *outputFormat) Type() string {
+	names := make([]string, len(outputFormats))
+	for i, format := range outputFormats {
+		names[i] = string(format)
+	}
+	return strings.Join(names, "|")
+}
+
+type sessionOutput struct {
+	Name         string    `json:"name"`
+	Id           string    `json:"id"`
+	Windows      int       `json:"windows"`
+	Attached     int       `json:"attached"`
+	Created      time.Time `json:"created"`
+	LastActivity time.Time `json:"last_activity"`
+	Group        string    `json:"group,omitempty"`
+	Path         string    `json:"path"`
+	Tags         []string  `json:"tags,omitempty"`
+}
+
+func writeSessions(w io.Writer, format outputFormat, sessions []tsm.Session) error {
+	switch format {
+	case outputJSON:
+		out := make([]sessionOutput, len(sessions))
+		for i, s := range sessions {
+			out[i] = sessionOutput{s.Name, s.Id, s.Windows, s.Attached, s.Created, s.LastActivity, s.Group, s.Path, s.Tags}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(out)
+	case outputPlain:
+		for _, s := range sessions {
+			if _, err := fmt.Fprintln(w, s.Name); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tWINDOWS\tATTACHED\tACTIVITY\tPATH\tTAGS")
+		for _, s := range sessions {
+			attached := "no"
+			if s.Attached > 0 {
+				attached = "yes"
+			}
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\n", s.Name, s.Windows, attached, s.LastActivity.Format(time.DateTime), s.Path, strings.Join(s.Tags, ","))
+		}
+		return tw.Flush()
+	}
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"github.com/iomallach/tmux-session-manager/internal/tsm"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// clientTTY is the client switches move, the current one when empty.
+var clientTTY string
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&clientTTY, "client", "", "tty of the tmux client to switch instead of the current one, like switch-client -c")
+	rootCmd.PersistentFlags().BoolVar(&config.Compact, "compact", config.Compact, "leave out the preview and the padding around the list")
+	rootCmd.AddCommand(&popupCmd)
+}
+
+var popupCmd = cobra.Command{
+	Use:   "popup",
+	Short: "Open tsm in a tmux popup over the current client",
+	Long: `Open tsm in a tmux popup over the current client, sized after the client
+and the number of sessions. Clients too narrow for the preview get the
+compact layout.
+
+Bind it in tmux.conf, passing the client the key was pressed in so the
+switch happens there:
+
+  bind-key s run-shell -b "tsm popup --client '#{client_tty}'"`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(os.Getenv("TMUX")) == 0 {
+			fail(errors.New("popups need tsm to run inside tmux"))
+		}
+		tmux := newTmux()
+		client, err := tmux.CurrentClient()
+		if err != nil {
+			fail(err)
+		}
+		sessions, err := tmux.TmuxListSessions()
+		if err != nil {
+			fail(err)
+		}
+		width, height, compact := tsm.PopupSize(client, len(sessions), config.ListWidth)
+		self, err := os.Executable()
+		if err != nil {
+			fail(err)
+		}
+
+		command := append([]string{self}, forwardedFlags(cmd)...)
+		if !cmd.Flags().Changed("client") {
+			command = append(command, "--client", client.TTY)
+		}
+		if compact && !config.Compact {
+			command = append(command, "--compact")
+		}
+		if err := tmux.Popup(client, width, height, command); err != nil {
+			fail(err)
+		}
+	},
+}
+
+// forwardedFlags are the flags tsm was given, so tsm in the popup runs with
+// the same ones.
+func forwardedFlags(cmd *cobra.Command) []string {
+	var args []string
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		if values, ok := flag.Value.(pflag.SliceValue); ok {
+			for _, value := range values.GetSlice() {
+				args = append(args, "--"+flag.Name+"="+value)
+			}
+			return
+		}
+		args = append(args, "--"+flag.Name+"="+flag.Value.String())
+	})
+	return args
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"github.com/iomallach/tmux-session-manager/internal/tsm"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(&renameCmd)
+}
+
+var renameCmd = cobra.Command{
+	Use:   "rename <old> <new>",
+	Short: "Rename a session",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		tmux := newTmux()
+		name, err := checkName(tmux, args[1], args[0])
+		if err != nil {
+			fail(err)
+		}
+		if err := tmux.TmuxRenameSession(args[0], name); err != nil {
+			fail(err)
+		}
+		pins, err := tsm.LoadPins(config.PinsPath)
+		if err != nil {
+			fail(err)
+		}
+		if pins.SlotOf(args[0]) > 0 {
+			pins.Rename(args[0], name)
+			if err := tsm.SavePins(config.PinsPath, pins); err != nil {
+				fail(err)
+			}
+		}
+	},
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -9,21 +10,97 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var config = tsm.DefaultConfig()
+
+var socketName, socketPath string
+
+var configPath = tsm.DefaultConfigPath()
+
+// loadConfig reads the config file into config. Only a file asked for with
+// --config has to exist.
+func loadConfig(cmd *cobra.Command, args []string) {
+	err := tsm.LoadConfigFile(configPath, &config)
+	if errors.Is(err, os.ErrNotExist) && !cmd.Flags().Changed("config") {
+		return
+	}
+	if err != nil {
+		fail(err)
+	}
+}
+
+// checkName applies the naming rules of config to a new session name, except
+// is the name of the session being renamed.
+func checkName(tmux *tsm.Tmux, name string, except string) (string, error) {
+	if config.SanitizeSessionNames {
+		name = tsm.SessionNameFor(name)
+	}
+	sessions, err := tmux.TmuxListSessions()
+	if err != nil {
+		return name, err
+	}
+	return name, tsm.ValidateSessionName(name, sessions, except, config.MaxSessionNameLength)
+}
+
+// newTmux talks to the server picked with --socket-name or --socket-path,
+// switches the client picked with --client and records the switches it
+// makes in the history.
+func newTmux() *tsm.Tmux {
+	tmux := tsm.NewTmux(socketName, socketPath)
+	tmux.UseClient(clientTTY)
+	tmux.RecordSwitches(config.HistoryPath)
+	return tmux
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", configPath, "config file with keys, theme and list options")
+	rootCmd.PersistentFlags().StringVarP(&socketName, "socket-name", "L", "", "use the tmux server on this named socket, like tmux -L")
+	rootCmd.PersistentFlags().StringVarP(&socketPath, "socket-path", "S", "", "use the tmux server on the socket at this path, like tmux -S")
+	rootCmd.MarkFlagsMutuallyExclusive("socket-name", "socket-path")
+	rootCmd.PersistentFlags().StringSliceVar(&config.ProjectRoots, "project-root", config.ProjectRoots, "directory to scan for projects, can be repeated")
+	rootCmd.PersistentFlags().IntVar(&config.ProjectDepth, "project-depth", config.ProjectDepth, "how many levels below a project root to look for projects")
+	rootCmd.PersistentFlags().StringSliceVar(&config.ProjectMarkers, "project-marker", config.ProjectMarkers, "file or directory that marks a project root")
+	rootCmd.PersistentFlags().StringSliceVar(&config.ProtectedSessions, "protect", config.ProtectedSessions, "glob pattern of session names that cannot be killed, can be repeated")
+	rootCmd.PersistentFlags().StringVar(&config.TrashPath, "trash-file", config.TrashPath, "where killed sessions are kept so they can be brought back")
+	rootCmd.PersistentFlags().DurationVar(&config.TrashRetention, "trash-retention", config.TrashRetention, "how long killed sessions can be brought back")
+	rootCmd.PersistentFlags().IntVar(&config.MaxSessionNameLength, "max-name-length", config.MaxSessionNameLength, "longest session name tsm accepts, 0 for no limit")
+	rootCmd.PersistentFlags().StringVar(&config.DefaultSession, "default-session", config.DefaultSession, "session started outside tmux when there is none, named after the current directory when empty")
+	rootCmd.PersistentFlags().BoolVar(&config.SanitizeSessionNames, "sanitize-names", config.SanitizeSessionNames, `replace "." and ":" in session names instead of refusing them`)
+}
+
 var rootCmd = &cobra.Command{
 	Use:   "tsm",
 	Short: "Tmux session manager is a very simple tui session manager for tmux",
+	// Execute reports errors itself so they are not printed twice.
+	SilenceErrors:    true,
+	PersistentPreRun: loadConfig,
 	Run: func(cmd *cobra.Command, args []string) {
-		p := tea.NewProgram(tsm.InitialRootModel(&tsm.Tmux{}))
+		tmux := newTmux()
+		// With no session to pick from there is nothing to show, start one
+		// and go straight to it.
+		started, err := tsm.StartDefaultSession(tmux, config.DefaultSession)
+		if err != nil {
+			fail(err)
+		}
+		if started {
+			if err := tmux.Attach(); err != nil {
+				fail(err)
+			}
+			return
+		}
+		p := tea.NewProgram(tsm.InitialRootModel(tmux, config))
 		if _, err := p.Run(); err != nil {
 			fmt.Printf("Alas, there's been an error: %v", err)
 			os.Exit(1)
 		}
+		if err := tmux.Attach(); err != nil {
+			fail(err)
+		}
 	},
 }
 
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprint(os.Stderr, err)
-		os.Exit(1)
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(exitUsage)
 	}
 }
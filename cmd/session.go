@@ -18,10 +18,14 @@ var sessionCmd = cobra.Command{
 	Use:   "sessions",
 	Short: "Manage tmux sessions",
 	Run: func(cmd *cobra.Command, args []string) {
-		p := tea.NewProgram(tsm.InitialSessionModel(&tsm.Tmux{}))
+		tmux := newTmux()
+		p := tea.NewProgram(tsm.InitialSessionModel(tmux, config))
 		if _, err := p.Run(); err != nil {
 			fmt.Printf("Alas, there's been an error: %v", err)
 			os.Exit(1)
 		}
+		if err := tmux.Attach(); err != nil {
+			fail(err)
+		}
 	},
 }
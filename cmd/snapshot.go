@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/iomallach/tmux-session-manager/internal/tsm"
+	"github.com/spf13/cobra"
+)
+
+var withScrollback bool
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&config.SnapshotPath, "snapshot-file", config.SnapshotPath, "where snapshots are saved and restored from")
+	snapshotSaveCmd.Flags().BoolVar(&withScrollback, "scrollback", false, "save the scrollback of every pane too")
+	snapshotCmd.AddCommand(&snapshotSaveCmd, &snapshotRestoreCmd)
+	rootCmd.AddCommand(&snapshotCmd)
+}
+
+var snapshotCmd = cobra.Command{
+	Use:   "snapshot",
+	Short: "Save sessions and bring them back after the tmux server restarts",
+}
+
+var snapshotSaveCmd = cobra.Command{
+	Use:   "save",
+	Short: "Save every session, window and pane",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		snapshot, err := tsm.TakeSnapshot(newTmux(), withScrollback)
+		if err != nil {
+			fail(err)
+		}
+		if err := tsm.SaveSnapshot(config.SnapshotPath, snapshot); err != nil {
+			fail(err)
+		}
+		fmt.Printf("Saved %d sessions to %s\n", len(snapshot.Sessions), config.SnapshotPath)
+	},
+}
+
+var snapshotRestoreCmd = cobra.Command{
+	Use:   "restore [session...]",
+	Short: "Restore saved sessions that are not running, all of them unless named",
+	Run: func(cmd *cobra.Command, args []string) {
+		snapshot, err := tsm.LoadSnapshot(config.SnapshotPath)
+		if err != nil {
+			fail(err)
+		}
+		restored, err := tsm.RestoreSnapshot(newTmux(), snapshot, args...)
+		for _, name := range restored {
+			fmt.Printf("Restored %s\n", name)
+		}
+		if err != nil {
+			fail(err)
+		}
+	},
+}
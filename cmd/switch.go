@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"github.com/iomallach/tmux-session-manager/internal/tsm"
+	"github.com/spf13/cobra"
+)
+
+var switchLast bool
+
+func init() {
+	switchCmd.Flags().BoolVar(&switchLast, "last", false, "switch to the session used before the current one instead of a named one")
+	rootCmd.AddCommand(&switchCmd)
+}
+
+var switchCmd = cobra.Command{
+	Use:   "switch [<name> | --last]",
+	Short: "Switch the current client to a session, or attach to it from outside tmux",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if switchLast {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		tmux := newTmux()
+		if switchLast {
+			history, err := tsm.LoadHistory(config.HistoryPath)
+			if err != nil {
+				fail(err)
+			}
+			if err := tsm.SwitchToPrevious(tmux, history); err != nil {
+				fail(err)
+			}
+		} else if err := tmux.TmuxSwitchSession(args[0]); err != nil {
+			fail(err)
+		}
+		if err := tmux.Attach(); err != nil {
+			fail(err)
+		}
+	},
+}
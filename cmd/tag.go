@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/iomallach/tmux-session-manager/internal/tsm"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(&tagCmd)
+}
+
+var tagCmd = cobra.Command{
+	Use:   "tag <session> [tag...]",
+	Short: "Show the tags of a session, or replace them",
+	Long: `Show the tags of a session, or replace them. Tags are kept in the
+` + tsm.TagsOption + ` option of the session, give "" to remove them all.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		tmux := newTmux()
+		if len(args) == 1 {
+			sessions, err := tmux.TmuxListSessions()
+			if err != nil {
+				fail(err)
+			}
+			for _, session := range sessions {
+				if session.Name == args[0] {
+					fmt.Println(strings.Join(session.Tags, ","))
+					return
+				}
+			}
+			fail(fmt.Errorf("%s: %w", args[0], tsm.ErrSessionNotFound))
+		}
+		if err := tmux.TmuxSetTags(args[0], tsm.ParseTags(strings.Join(args[1:], " "))); err != nil {
+			fail(err)
+		}
+	},
+}
@@ -0,0 +1,197 @@
+package tsm
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+type choiceKind int
+
+const (
+	SESSION_CHOICE choiceKind = iota
+	PROJECT_CHOICE
+	LAYOUT_CHOICE
+	SNAPSHOT_CHOICE
+	// PIN_CHOICE is a pin whose session is not running.
+	PIN_CHOICE
+	// TAG_CHOICE is the header of the sessions with a tag.
+	TAG_CHOICE
+)
+
+// choice is a row of the session list: either a running session or
+// something a session can be made from.
+type choice struct {
+	kind     choiceKind
+	session  Session
+	project  Project
+	layout   Layout
+	snapshot SnapshotSession
+	pin      Pin
+	// slot is the pin slot of the choice, 0 when it is not pinned.
+	slot int
+	// tag, members and collapsed describe a TAG_CHOICE header.
+	tag       string
+	members   int
+	collapsed bool
+	// grouped sessions are indented under their header.
+	grouped bool
+}
+
+func (c choice) name() string {
+	switch c.kind {
+	case PROJECT_CHOICE:
+		return c.project.Name
+	case LAYOUT_CHOICE:
+		return c.layout.Name
+	case SNAPSHOT_CHOICE:
+		return c.snapshot.Name
+	case PIN_CHOICE:
+		return c.pin.Session
+	case TAG_CHOICE:
+		// Session names cannot have a colon, so this never is one.
+		return tagPrefix + c.tag
+	default:
+		return c.session.Name
+	}
+}
+
+func sessionChoices(sessions []Session) []choice {
+	choices := make([]choice, len(sessions))
+	for i, session := range sessions {
+		choices[i] = choice{kind: SESSION_CHOICE, session: session}
+	}
+	return choices
+}
+
+// pinChoices returns the pins in slot order, as their session when it is
+// running and as the pin itself when it is not.
+func pinChoices(pins Pins, sessions []Session) []choice {
+	var choices []choice
+	for _, pin := range pins.Pins {
+		i := slices.IndexFunc(sessions, func(s Session) bool { return s.Name == pin.Session })
+		if i < 0 {
+			choices = append(choices, choice{kind: PIN_CHOICE, pin: pin, slot: pin.Slot})
+		} else {
+			choices = append(choices, choice{kind: SESSION_CHOICE, session: sessions[i], slot: pin.Slot})
+		}
+	}
+	return choices
+}
+
+// projectChoices returns the projects that do not have a session yet, either
+// under their own name or in their directory.
+func projectChoices(projects []Project, sessions []Session) []choice {
+	var choices []choice
+	for _, project := range projects {
+		opened := slices.ContainsFunc(sessions, func(s Session) bool {
+			return s.Name == project.Name || s.Path == project.Path
+		})
+		if !opened {
+			choices = append(choices, choice{kind: PROJECT_CHOICE, project: project})
+		}
+	}
+	return choices
+}
+
+// layoutChoices returns the layouts whose session is not running yet.
+func layoutChoices(layouts []Layout, sessions []Session) []choice {
+	var choices []choice
+	for _, layout := range layouts {
+		if !slices.ContainsFunc(sessions, func(s Session) bool { return s.Name == layout.Name }) {
+			choices = append(choices, choice{kind: LAYOUT_CHOICE, layout: layout})
+		}
+	}
+	return choices
+}
+
+// snapshotChoices returns the saved sessions that are not running.
+func snapshotChoices(snapshot []SnapshotSession, sessions []Session) []choice {
+	var choices []choice
+	for _, saved := range snapshot {
+		if !slices.ContainsFunc(sessions, func(s Session) bool { return s.Name == saved.Name }) {
+			choices = append(choices, choice{kind: SNAPSHOT_CHOICE, snapshot: saved})
+		}
+	}
+	return choices
+}
+
+func (s Styles) renderChoice(c choice, positions []int, cursor string, style lipgloss.Style) string {
+	if c.grouped {
+		cursor += "  "
+	}
+	cursor = style.Render(cursor)
+	if c.slot > 0 {
+		cursor += s.mark.Render(fmt.Sprintf("%d ", c.slot))
+	}
+	switch c.kind {
+	case PROJECT_CHOICE:
+		return cursor +
+			s.highlight(c.project.Name, positions, style) +
+			s.dim.Render(" (project)")
+	case LAYOUT_CHOICE:
+		return cursor +
+			s.highlight(c.layout.Name, positions, style) +
+			s.dim.Render(" (layout)")
+	case SNAPSHOT_CHOICE:
+		return cursor +
+			s.highlight(c.snapshot.Name, positions, s.dim) +
+			s.dim.Render(fmt.Sprintf(": %d windows (saved)", len(c.snapshot.Windows)))
+	case PIN_CHOICE:
+		return cursor +
+			s.highlight(c.pin.Session, positions, s.dim) +
+			s.dim.Render(" (pinned)")
+	case TAG_CHOICE:
+		fold := "▾ "
+		if c.collapsed {
+			fold = "▸ "
+		}
+		return cursor +
+			style.Bold(true).Render(fold+c.tag) +
+			s.dim.Render(fmt.Sprintf(" (%d)", c.members))
+	default:
+		attached := ""
+		if c.session.Attached > 0 {
+			attached = " (attached)"
+		}
+		return cursor +
+			s.highlight(c.session.Name, positions, style) +
+			style.Render(fmt.Sprintf(": %d windows%s", c.session.Windows, attached))
+	}
+}
+
+// highlight renders the runes of text at the given positions with m.styles.match
+// and everything else with style.
+func (s Styles) highlight(text string, positions []int, style lipgloss.Style) string {
+	if len(positions) == 0 {
+		return style.Render(text)
+	}
+
+	var b strings.Builder
+	var run []rune
+	matched := false
+	flush := func() {
+		if len(run) == 0 {
+			return
+		}
+		if matched {
+			b.WriteString(s.match.Render(string(run)))
+		} else {
+			b.WriteString(style.Render(string(run)))
+		}
+		run = run[:0]
+	}
+	for i, r := range []rune(text) {
+		isMatch := slices.Contains(positions, i)
+		if isMatch != matched {
+			flush()
+			matched = isMatch
+		}
+		run = append(run, r)
+	}
+	flush()
+
+	return b.String()
+}
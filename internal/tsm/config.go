@@ -0,0 +1,141 @@
+package tsm
+
+import (
+	"errors"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrProtectedSession is returned for sessions tsm refuses to kill.
+var ErrProtectedSession = errors.New("session is protected")
+
+// Config holds the user facing options that shape the models.
+type Config struct {
+	ProjectRoots   []string
+	ProjectDepth   int
+	ProjectMarkers []string
+	SnapshotPath   string
+	// ProtectedSessions are glob patterns of session names that cannot be
+	// killed from tsm.
+	ProtectedSessions []string
+	// TrashPath is where killed sessions are kept for TrashRetention so
+	// they can be brought back.
+	TrashPath      string
+	TrashRetention time.Duration
+	// HistoryPath is where switches are recorded for SORT_FRECENCY.
+	HistoryPath string
+	// PinsPath is where the pinned sessions are kept.
+	PinsPath string
+	// MaxSessionNameLength limits new session names, 0 means no limit.
+	MaxSessionNameLength int
+	// SanitizeSessionNames replaces "." and ":" in names as they are typed,
+	// the way tmux would, instead of refusing them.
+	SanitizeSessionNames bool
+	// Keys rebinds the actions of the session list, see manageActions for
+	// their names.
+	Keys map[string][]string
+	// Theme names one of the built in themes, one of Themes or AUTO_THEME.
+	Theme string
+	// Themes are the themes defined in the config file.
+	Themes    map[string]Theme
+	ListWidth int
+	// Compact drops the preview and the padding around the list, for small
+	// popups.
+	Compact   bool
+	SortOrder SortOrder
+	// DefaultMode is what tsm shows first.
+	DefaultMode Mode
+	// DefaultSession names the session started when there is none to pick,
+	// the current directory names it when empty.
+	DefaultSession string
+}
+
+// SortOrder is how sessions are ordered in the list.
+type SortOrder int
+
+const (
+	// SORT_NONE keeps the order tmux lists the sessions in.
+	SORT_NONE SortOrder = iota
+	SORT_NAME
+	// SORT_ACTIVITY puts the most recently used sessions first.
+	SORT_ACTIVITY
+	// SORT_CREATED keeps the sessions in the order they were created.
+	SORT_CREATED
+	// SORT_FRECENCY ranks sessions by how often and how recently they were
+	// switched to, with the current session first and the previous second.
+	SORT_FRECENCY
+)
+
+var sortOrders = map[string]SortOrder{
+	"tmux":     SORT_NONE,
+	"name":     SORT_NAME,
+	"activity": SORT_ACTIVITY,
+	"created":  SORT_CREATED,
+	"frecency": SORT_FRECENCY,
+}
+
+// Mode is where tsm starts.
+type Mode int
+
+const (
+	SESSIONS_MODE Mode = iota
+	// SERVERS_MODE starts on the list of servers.
+	SERVERS_MODE
+	// FILTER_MODE starts on the sessions with the search already open.
+	FILTER_MODE
+)
+
+var modes = map[string]Mode{
+	"sessions": SESSIONS_MODE,
+	"servers":  SERVERS_MODE,
+	"filter":   FILTER_MODE,
+}
+
+func DefaultConfig() Config {
+	return Config{
+		ProjectDepth:         2,
+		ProjectMarkers:       []string{".git", "go.mod", "package.json", "Cargo.toml", "pyproject.toml"},
+		SnapshotPath:         DefaultSnapshotPath(),
+		TrashPath:            DefaultTrashPath(),
+		TrashRetention:       24 * time.Hour,
+		HistoryPath:          DefaultHistoryPath(),
+		PinsPath:             DefaultPinsPath(),
+		MaxSessionNameLength: 32,
+		Theme:                AUTO_THEME,
+		ListWidth:            DefaultListWidth,
+		SortOrder:            SORT_FRECENCY,
+	}
+}
+
+// SortSessions orders the sessions in place. Only SORT_FRECENCY looks at
+// the history and the current session.
+func SortSessions(sessions []Session, order SortOrder, history History, current string) {
+	switch order {
+	case SORT_NONE:
+		return
+	case SORT_FRECENCY:
+		history.sortByFrecency(sessions, current, time.Now())
+		return
+	}
+	slices.SortStableFunc(sessions, func(a, b Session) int {
+		switch order {
+		case SORT_ACTIVITY:
+			return b.LastActivity.Compare(a.LastActivity)
+		case SORT_CREATED:
+			return a.Created.Compare(b.Created)
+		default:
+			return strings.Compare(a.Name, b.Name)
+		}
+	})
+}
+
+// Protected reports whether the session name matches one of the protected
+// patterns.
+func (c Config) Protected(name string) bool {
+	return slices.ContainsFunc(c.ProtectedSessions, func(pattern string) bool {
+		matched, _ := path.Match(pattern, name)
+		return matched
+	})
+}
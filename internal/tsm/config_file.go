@@ -0,0 +1,259 @@
+package tsm
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// minListWidth keeps the list wide enough for the forms drawn in it.
+const minListWidth = 30
+
+// configFile is the layout of config.toml.
+type configFile struct {
+	Theme          string                       `toml:"theme"`
+	ListWidth      int                          `toml:"list_width"`
+	Sort           string                       `toml:"sort"`
+	DefaultMode    string                       `toml:"default_mode"`
+	DefaultSession string                       `toml:"default_session"`
+	Keys           map[string][]string          `toml:"keys"`
+	Themes         map[string]map[string]string `toml:"themes"`
+}
+
+// ConfigProblem is one thing wrong in a config file. Line is 0 when the key
+// could not be found in the file.
+type ConfigProblem struct {
+	Line    int
+	Key     string
+	Message string
+}
+
+// ConfigError lists everything wrong with a config file at once, so it can
+// be fixed in one go.
+type ConfigError struct {
+	Path     string
+	Problems []ConfigProblem
+}
+
+func (e *ConfigError) Error() string {
+	lines := make([]string, len(e.Problems))
+	for i, problem := range e.Problems {
+		lines[i] = e.Path
+		if problem.Line > 0 {
+			lines[i] += fmt.Sprintf(":%d", problem.Line)
+		}
+		if len(problem.Key) > 0 {
+			lines[i] += ": " + problem.Key
+		}
+		lines[i] += ": " + problem.Message
+	}
+	return strings.Join(lines, "\n")
+}
+
+// DefaultConfigPath is config.toml under $XDG_CONFIG_HOME or ~/.config.
+func DefaultConfigPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if len(dir) == 0 {
+		dir = expandHome("~/.config")
+	}
+	return filepath.Join(dir, "tsm", "config.toml")
+}
+
+// LoadConfigFile applies the config file at path on top of config. Nothing
+// is applied when the file has problems, they are all returned in a
+// *ConfigError instead.
+func LoadConfigFile(path string, config *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var file configFile
+	md, err := toml.Decode(string(data), &file)
+	var parseErr toml.ParseError
+	if errors.As(err, &parseErr) {
+		message := parseErr.Message
+		if len(message) == 0 {
+			// Some messages are only found in Error, behind the position.
+			prefix := fmt.Sprintf("toml: line %d", parseErr.Position.Line)
+			if len(parseErr.LastKey) > 0 {
+				prefix += fmt.Sprintf(" (last key %q)", parseErr.LastKey)
+			}
+			message = strings.TrimPrefix(parseErr.Error(), prefix+": ")
+		}
+		// Counted from the offset, the line of an error found at a newline is
+		// the one the newline ends rather than the next.
+		line := bytes.Count(data[:min(parseErr.Position.Start, len(data))], []byte("\n")) + 1
+		return &ConfigError{Path: path, Problems: []ConfigProblem{{Line: line, Key: parseErr.LastKey, Message: message}}}
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	var problems []ConfigProblem
+	problem := func(key toml.Key, format string, args ...any) {
+		problems = append(problems, ConfigProblem{Line: keyLine(data, key), Key: strings.Join(key, "."), Message: fmt.Sprintf(format, args...)})
+	}
+
+	undecoded := md.Undecoded()
+	for _, key := range undecoded {
+		// Everything in an unknown table is unknown too, the table is enough.
+		if len(key) > 1 && slices.ContainsFunc(undecoded, func(k toml.Key) bool { return slices.Equal(k, key[:len(key)-1]) }) {
+			continue
+		}
+		problem(key, "unknown key")
+	}
+	for _, name := range sortedKeys(file.Themes) {
+		if _, ok := themes[name]; ok || name == AUTO_THEME {
+			problem(toml.Key{"themes", name}, "%s is a built in theme", name)
+		}
+		for _, color := range sortedKeys(file.Themes[name]) {
+			if _, ok := themeColors[color]; !ok {
+				problem(toml.Key{"themes", name, color}, "unknown color, expected one of %s", strings.Join(sortedKeys(themeColors), ", "))
+			} else if !validColor(file.Themes[name][color]) {
+				problem(toml.Key{"themes", name, color}, "%q is neither a #rrggbb color nor an ANSI color number", file.Themes[name][color])
+			}
+		}
+	}
+	_, builtin := themes[file.Theme]
+	_, defined := file.Themes[file.Theme]
+	if md.IsDefined("theme") && !builtin && !defined && file.Theme != AUTO_THEME {
+		known := append(sortedKeys(themes), AUTO_THEME)
+		known = append(known, sortedKeys(file.Themes)...)
+		problem(toml.Key{"theme"}, "unknown theme %q, expected one of %s", file.Theme, strings.Join(known, ", "))
+	}
+	if md.IsDefined("list_width") && file.ListWidth < minListWidth {
+		problem(toml.Key{"list_width"}, "must be at least %d", minListWidth)
+	}
+	if _, ok := sortOrders[file.Sort]; md.IsDefined("sort") && !ok {
+		problem(toml.Key{"sort"}, "unknown sort order %q, expected one of %s", file.Sort, strings.Join(sortedKeys(sortOrders), ", "))
+	}
+	if _, ok := modes[file.DefaultMode]; md.IsDefined("default_mode") && !ok {
+		problem(toml.Key{"default_mode"}, "unknown mode %q, expected one of %s", file.DefaultMode, strings.Join(sortedKeys(modes), ", "))
+	}
+	problems = append(problems, checkKeys(data, file.Keys)...)
+	if len(problems) > 0 {
+		slices.SortStableFunc(problems, func(a, b ConfigProblem) int { return a.Line - b.Line })
+		return &ConfigError{Path: path, Problems: problems}
+	}
+
+	if md.IsDefined("theme") {
+		config.Theme = file.Theme
+	}
+	if md.IsDefined("list_width") {
+		config.ListWidth = file.ListWidth
+	}
+	if md.IsDefined("sort") {
+		config.SortOrder = sortOrders[file.Sort]
+	}
+	if md.IsDefined("default_mode") {
+		config.DefaultMode = modes[file.DefaultMode]
+	}
+	if md.IsDefined("default_session") {
+		config.DefaultSession = file.DefaultSession
+	}
+	if len(file.Keys) > 0 {
+		config.Keys = file.Keys
+	}
+	for name, colors := range file.Themes {
+		if config.Themes == nil {
+			config.Themes = map[string]Theme{}
+		}
+		config.Themes[name] = userTheme(colors)
+	}
+	return nil
+}
+
+// validColor accepts what lipgloss.Color understands: #rgb or #rrggbb hex
+// colors and ANSI color numbers.
+func validColor(color string) bool {
+	if hex, ok := strings.CutPrefix(color, "#"); ok {
+		_, err := strconv.ParseUint(hex, 16, 32)
+		return err == nil && (len(hex) == 3 || len(hex) == 6)
+	}
+	n, err := strconv.Atoi(color)
+	return err == nil && n >= 0 && n <= 255
+}
+
+// checkKeys finds actions that do not exist, have no keys left, or share a
+// key with another action once rebound.
+func checkKeys(data []byte, keys map[string][]string) []ConfigProblem {
+	var problems []ConfigProblem
+	problem := func(action string, format string, args ...any) {
+		key := toml.Key{"keys", action}
+		problems = append(problems, ConfigProblem{Line: keyLine(data, key), Key: strings.Join(key, "."), Message: fmt.Sprintf(format, args...)})
+	}
+
+	for _, action := range sortedKeys(keys) {
+		if _, ok := manageActions[action]; !ok {
+			problem(action, "unknown action, expected one of %s", strings.Join(sortedKeys(manageActions), ", "))
+		} else if len(keys[action]) == 0 {
+			problem(action, "needs at least one key")
+		}
+	}
+
+	km := manageKeys(keys)
+	bound := map[string]string{}
+	for _, action := range sortedKeys(manageActions) {
+		for _, k := range manageActions[action](&km).Keys() {
+			other, taken := bound[k]
+			bound[k] = action
+			if !taken || other == action {
+				continue
+			}
+			// Blame whichever of the two was rebound in the file.
+			if _, rebound := keys[action]; rebound {
+				problem(action, "%q is already bound to %s", k, other)
+			} else {
+				problem(other, "%q is already bound to %s", k, action)
+			}
+		}
+	}
+	return problems
+}
+
+// keyLine finds the line of key in a TOML file, or 0 when it is not there.
+// It understands tables and dotted keys, which is all a config file needs.
+func keyLine(data []byte, key toml.Key) int {
+	want := strings.Join(key, ".")
+	table := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(text, "[") {
+			table = unquoteKey(strings.Trim(text, "[] "))
+			if table == want {
+				return line
+			}
+			continue
+		}
+		name, _, ok := strings.Cut(text, "=")
+		if !ok || strings.HasPrefix(text, "#") {
+			continue
+		}
+		name = unquoteKey(name)
+		if len(table) > 0 {
+			name = table + "." + name
+		}
+		if name == want {
+			return line
+		}
+	}
+	return 0
+}
+
+func unquoteKey(key string) string {
+	parts := strings.Split(key, ".")
+	for i, part := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(part), `"'`)
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,161 @@
+package tsm
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	path := writeConfig(t, `
+theme = "latte"
+list_width = 60
+sort = "activity"
+default_mode = "filter"
+
+[keys]
+kill = ["D", "delete"]
+`)
+	config := DefaultConfig()
+	if err := LoadConfigFile(path, &config); err != nil {
+		t.Fatal(err)
+	}
+	if config.Theme != "latte" || config.ListWidth != 60 || config.SortOrder != SORT_ACTIVITY || config.DefaultMode != FILTER_MODE {
+		t.Errorf("Expected the options of the file, got %+v", config)
+	}
+	if keys := manageKeys(config.Keys).Delete.Keys(); !slices.Equal(keys, []string{"D", "delete"}) {
+		t.Errorf("Expected kill to be rebound, got %v", keys)
+	}
+}
+
+func TestLoadConfigFileReportsEveryProblem(t *testing.T) {
+	path := writeConfig(t, `theme = "dracula"
+list_width = 10
+colour = "red"
+
+[keys]
+kill = ["x"]
+teleport = ["g"]
+`)
+	config := DefaultConfig()
+	err := LoadConfigFile(path, &config)
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) {
+		t.Fatalf("Expected a ConfigError, got %v", err)
+	}
+
+	expected := []struct {
+		line int
+		key  string
+	}{{1, "theme"}, {2, "list_width"}, {3, "colour"}, {6, "keys.kill"}, {7, "keys.teleport"}}
+	if len(configErr.Problems) != len(expected) {
+		t.Fatalf("Expected %d problems, got %v", len(expected), configErr)
+	}
+	for i, problem := range configErr.Problems {
+		if problem.Line != expected[i].line || problem.Key != expected[i].key {
+			t.Errorf("Expected %s on line %d, got %s on line %d", expected[i].key, expected[i].line, problem.Key, problem.Line)
+		}
+	}
+	if config.Theme != AUTO_THEME {
+		t.Errorf("Expected nothing to be applied from a broken file, got theme %s", config.Theme)
+	}
+}
+
+func TestLoadConfigFileSyntaxError(t *testing.T) {
+	path := writeConfig(t, "sort = \"name\"\ntheme = \n")
+	var configErr *ConfigError
+	if err := LoadConfigFile(path, &Config{}); !errors.As(err, &configErr) || configErr.Problems[0].Line != 2 {
+		t.Errorf("Expected a problem on line 2, got %v", err)
+	}
+}
+
+func TestLoadConfigFileMissing(t *testing.T) {
+	if err := LoadConfigFile(filepath.Join(t.TempDir(), "config.toml"), &Config{}); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected a missing file to be reported as such, got %v", err)
+	}
+}
+
+func TestKeyLine(t *testing.T) {
+	data := []byte(`# keys.kill = ["k"]
+theme = "mocha"
+keys.up = ["w"]
+
+[keys]
+"kill" = ["D"]
+`)
+	tests := []struct {
+		key      []string
+		expected int
+	}{
+		{[]string{"theme"}, 2},
+		{[]string{"keys", "up"}, 3},
+		{[]string{"keys"}, 5},
+		{[]string{"keys", "kill"}, 6},
+		{[]string{"sort"}, 0},
+	}
+	for _, test := range tests {
+		if line := keyLine(data, test.key); line != test.expected {
+			t.Errorf("Expected %v on line %d, got %d", test.key, test.expected, line)
+		}
+	}
+}
+
+func TestReboundKeysDriveTheSessionList(t *testing.T) {
+	tmux := &MockTmux{sessions: testSessions("main", "scratch")}
+	test_model := InitialSessionModel(tmux, Config{Keys: map[string][]string{"kill": {"D"}, "down": {"n"}}})
+
+	updModel, _ := sendKeys(test_model, "j", "d")
+	if m := updModel.(model); m.cursor != 0 || m.state != MANAGE_STATE {
+		t.Errorf("Expected the old keys to do nothing, got cursor %d and state %d", m.cursor, m.state)
+	}
+	updModel, _ = sendKeys(updModel, "n", "D")
+	if m := updModel.(model); m.cursor != 1 || m.state != CONFIRM_STATE {
+		t.Errorf("Expected the new keys to move down and kill, got cursor %d and state %d", m.cursor, m.state)
+	}
+}
+
+func TestSortSessions(t *testing.T) {
+	now := time.Now()
+	sessions := []Session{
+		{Name: "b", Created: now.Add(-time.Hour), LastActivity: now},
+		{Name: "c", Created: now.Add(-2 * time.Hour), LastActivity: now.Add(-time.Minute)},
+		{Name: "a", Created: now, LastActivity: now.Add(-time.Hour)},
+	}
+	tests := []struct {
+		order    SortOrder
+		expected []string
+	}{
+		{SORT_NONE, []string{"b", "c", "a"}},
+		{SORT_NAME, []string{"a", "b", "c"}},
+		{SORT_ACTIVITY, []string{"b", "c", "a"}},
+		{SORT_CREATED, []string{"c", "b", "a"}},
+	}
+	for _, test := range tests {
+		sorted := slices.Clone(sessions)
+		SortSessions(sorted, test.order, History{}, "")
+		if names := sessionNames(sorted); !slices.Equal(names, test.expected) {
+			t.Errorf("Expected order %d to give %v, got %v", test.order, test.expected, names)
+		}
+	}
+}
+
+func TestDefaultMode(t *testing.T) {
+	if m := InitialSessionModel(&MockTmux{}, Config{DefaultMode: FILTER_MODE}); !m.filtering {
+		t.Errorf("Expected the filter mode to start with the search open")
+	}
+	tmux := &MockTmux{servers: []Server{{Name: "default", Alive: true, Current: true}}}
+	if root := InitialRootModel(tmux, Config{DefaultMode: SERVERS_MODE}); root.state != CHOOSING {
+		t.Errorf("Expected the servers mode to start on the servers, got %d", root.state)
+	}
+}
@@ -0,0 +1,153 @@
+package tsm
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// killTarget is what the confirm dialog shows about a session before it is
+// killed.
+type killTarget struct {
+	session Session
+	windows []killWindow
+}
+
+type killWindow struct {
+	window   Window
+	commands []string
+}
+
+// busy reports whether anything other than a shell runs in the session.
+func (t killTarget) busy() bool {
+	for _, window := range t.windows {
+		for _, command := range window.commands {
+			if !slices.Contains(shells, command) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// describeKill gathers the windows and running commands of the sessions
+// about to be killed.
+func describeKill(tmux Tmuxer, sessions []Session) ([]killTarget, error) {
+	targets := make([]killTarget, len(sessions))
+	for i, session := range sessions {
+		targets[i].session = session
+		windows, err := tmux.TmuxListWindows(session.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, window := range windows {
+			panes, err := tmux.TmuxListPanes(window.Id)
+			if err != nil {
+				return nil, err
+			}
+			commands := make([]string, len(panes))
+			for j, pane := range panes {
+				commands[j] = pane.Command
+			}
+			targets[i].windows = append(targets[i].windows, killWindow{window: window, commands: commands})
+		}
+	}
+	return targets, nil
+}
+
+// confirmKill opens the confirm dialog for the named sessions, leaving out
+// the protected ones.
+func (m *model) confirmKill(names []string) {
+	var sessions []Session
+	var protected []string
+	for _, session := range m.sessions {
+		if !slices.Contains(names, session.Name) {
+			continue
+		}
+		if m.config.Protected(session.Name) {
+			protected = append(protected, session.Name)
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+	if len(sessions) == 0 {
+		if len(protected) > 0 {
+			m.err = fmt.Errorf("%s: %w", strings.Join(protected, ", "), ErrProtectedSession)
+		}
+		return
+	}
+
+	m.kill_targets, m.err = describeKill(m.tmux, sessions)
+	if m.err != nil {
+		return
+	}
+	m.kill_protected = protected
+	m.state = CONFIRM_STATE
+}
+
+func (m model) updateConfirmState(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(msg, default_confirm_keys.Yes):
+			names := make([]string, len(m.kill_targets))
+			for i, target := range m.kill_targets {
+				names[i] = target.session.Name
+			}
+			m.err = m.killSessions(names)
+			m.state = MANAGE_STATE
+			m.kill_targets = nil
+			m.kill_protected = nil
+			return m, m.requestPreview()
+		case key.Matches(msg, default_confirm_keys.No):
+			m.state = MANAGE_STATE
+			m.kill_targets = nil
+			m.kill_protected = nil
+		case key.Matches(msg, default_confirm_keys.Quit):
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+func (m model) viewConfirmState() string {
+	var b strings.Builder
+	for _, target := range m.kill_targets {
+		b.WriteString(m.styles.selected.Render(target.session.Name) + "\n")
+		if target.session.Attached > 0 {
+			b.WriteString(m.styles.warning.Render(fmt.Sprintf("  ! %d clients attached", target.session.Attached)) + "\n")
+		}
+		if target.busy() {
+			b.WriteString(m.styles.warning.Render("  ! still running programs") + "\n")
+		}
+		for _, window := range target.windows {
+			commands := make([]string, len(window.commands))
+			for i, command := range window.commands {
+				commands[i] = command
+				if !slices.Contains(shells, command) {
+					commands[i] = m.styles.warning.Render(command)
+				}
+			}
+			fmt.Fprintf(&b, "  %d: %s (%s)\n", window.window.Index, window.window.Name, strings.Join(commands, ", "))
+		}
+	}
+	if len(m.kill_protected) > 0 {
+		b.WriteString(m.styles.dim.Render("protected, kept: "+strings.Join(m.kill_protected, ", ")) + "\n")
+	}
+
+	return m.styles.root.Render(
+		fmt.Sprintf(
+			"%s\n%s\n%s",
+			m.styles.header.Render(fmt.Sprintf("Kill %d sessions?", len(m.kill_targets))),
+			m.styles.list.Render(strings.TrimRight(b.String(), "\n")),
+			m.styles.help.Render(fmt.Sprintf(
+				"%s to kill (%s brings them back), %s to cancel",
+				default_confirm_keys.Yes.Help().Key,
+				m.sessKeyMap.ManageKeyMap.Undo.Help().Key,
+				default_confirm_keys.No.Help().Key,
+			)),
+		),
+	)
+}
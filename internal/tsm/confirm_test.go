@@ -0,0 +1,79 @@
+package tsm
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func newTestConfirmModel(config Config) model {
+	sessions := testSessions("main", "scratch", "build")
+	sessions[0].Attached = 1
+	tmux := &MockTmux{
+		sessions: sessions,
+		windows: map[string][]Window{
+			"main":    testWindows("editor"),
+			"scratch": testWindows("shell"),
+			"build":   testWindows("shell"),
+		},
+		panes: map[string][]Pane{
+			"@editor": {{Id: "%1", Command: "nvim"}, {Id: "%2", Command: "zsh"}},
+			"@shell":  {{Id: "%3", Command: "zsh"}},
+		},
+	}
+	return InitialSessionModel(tmux, config)
+}
+
+func TestKillAsksForConfirmation(t *testing.T) {
+	updModel, _ := sendKeys(newTestConfirmModel(Config{}), "d")
+	test_model := updModel.(model)
+	if test_model.state != CONFIRM_STATE {
+		t.Fatalf("Expected state to be %d, got %d", CONFIRM_STATE, test_model.state)
+	}
+	target := test_model.kill_targets[0]
+	if target.session.Name != "main" || !target.busy() {
+		t.Errorf("Expected main to be busy running nvim, got %+v", target)
+	}
+	view := test_model.View()
+	for _, expected := range []string{"Kill 1 sessions?", "1 clients attached", "still running programs", "nvim"} {
+		if !strings.Contains(view, expected) {
+			t.Errorf("Expected dialog to mention %q", expected)
+		}
+	}
+
+	updModel, _ = sendKeys(test_model, "n")
+	test_model = updModel.(model)
+	if test_model.state != MANAGE_STATE || len(test_model.sessions) != 3 {
+		t.Errorf("Expected cancelling to keep every session, got %v", sessionNames(test_model.sessions))
+	}
+}
+
+func TestProtectedSessionsAreNotKilled(t *testing.T) {
+	config := Config{ProtectedSessions: []string{"ma*"}}
+	updModel, _ := sendKeys(newTestConfirmModel(config), "d")
+	test_model := updModel.(model)
+	if test_model.state != MANAGE_STATE || !errors.Is(test_model.err, ErrProtectedSession) {
+		t.Fatalf("Expected killing a protected session to fail, got state %d and %v", test_model.state, test_model.err)
+	}
+
+	updModel, _ = sendKeys(newTestConfirmModel(config), "*", "d")
+	test_model = updModel.(model)
+	if test_model.kill_protected[0] != "main" || len(test_model.kill_targets) != 2 {
+		t.Fatalf("Expected main to be left out of the bulk kill, got %v", test_model.kill_protected)
+	}
+	updModel, _ = sendKeys(test_model, "y")
+	if names := sessionNames(updModel.(model).sessions); !slices.Equal(names, []string{"main"}) {
+		t.Errorf("Expected only main to survive, got %v", names)
+	}
+}
+
+func TestConfigProtected(t *testing.T) {
+	config := Config{ProtectedSessions: []string{"main", "work-*"}}
+	tests := map[string]bool{"main": true, "work-api": true, "mainly": false, "scratch": false}
+	for name, expected := range tests {
+		if config.Protected(name) != expected {
+			t.Errorf("Expected %s to be protected: %v", name, expected)
+		}
+	}
+}
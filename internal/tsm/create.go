@@ -0,0 +1,258 @@
+package tsm
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// createFields are the fields of the create form in the order tab walks
+// through them.
+var createFields = []Input{NEW_SESSION_INPUT, NEW_SESSION_DIR_INPUT, NEW_SESSION_COMMAND_INPUT, NEW_SESSION_ATTACH}
+
+func createDirInputBubble() textinput.Model {
+	input := createSessionInputBubble("Current directory")
+	input.CharLimit = 0
+	input.Width = 27
+	input.ShowSuggestions = true
+	return input
+}
+
+// completeDir lists the directories that could follow what has been typed
+// so far, keeping the path as typed so ~ stays ~.
+func completeDir(value string) []string {
+	typed, base := "", value
+	if i := strings.LastIndex(value, "/"); i >= 0 {
+		typed, base = value[:i+1], value[i+1:]
+	}
+	dir := expandHome(typed)
+	if len(dir) == 0 {
+		dir = "."
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var dirs []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, base) || strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".") {
+			continue
+		}
+		// Stat rather than entry.IsDir so links to directories count too.
+		if info, err := os.Stat(filepath.Join(dir, name)); err != nil || !info.IsDir() {
+			continue
+		}
+		dirs = append(dirs, typed+name+"/")
+	}
+	return dirs
+}
+
+// validateDir checks the start directory of a new session, empty means the
+// current one.
+func validateDir(value string) error {
+	if len(value) == 0 {
+		return nil
+	}
+	info, err := os.Stat(expandHome(value))
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%s: no such directory", value)
+	}
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s: not a directory", value)
+	}
+	return nil
+}
+
+// focusField moves the create form to field, only the focused input shows
+// a cursor.
+func (m *model) focusField(field Input) {
+	m.focused = field
+	for _, f := range createFields {
+		if f == NEW_SESSION_ATTACH {
+			continue
+		}
+		if f == field {
+			m.inputs[f].Focus()
+		} else {
+			m.inputs[f].Blur()
+		}
+	}
+}
+
+func (m *model) resetCreateForm() {
+	for _, f := range createFields {
+		if f != NEW_SESSION_ATTACH {
+			m.inputs[f].Reset()
+		}
+	}
+	m.inputs[NEW_SESSION_DIR_INPUT].SetSuggestions(nil)
+	m.create_attach = false
+	m.focusField(NEW_SESSION_INPUT)
+}
+
+func (m model) updateCreateState(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	m.focusField(m.focused)
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		field := slices.Index(createFields, m.focused)
+		switch {
+		case key.Matches(msg, default_form_keys.Cancel):
+			m.state = MANAGE_STATE
+			m.resetCreateForm()
+			return m, nil
+		case key.Matches(msg, default_form_keys.Submit):
+			return m.createSession()
+		case key.Matches(msg, default_form_keys.Prev):
+			m.focusField(createFields[(field+len(createFields)-1)%len(createFields)])
+			return m, nil
+		case key.Matches(msg, default_form_keys.Next):
+			// Tab completes the directory until it names one, and moves on
+			// to the next field after that.
+			dir := m.inputs[NEW_SESSION_DIR_INPUT]
+			if m.focused != NEW_SESSION_DIR_INPUT || len(dir.CurrentSuggestion()) == 0 || validateDir(dir.Value()) == nil {
+				m.focusField(createFields[(field+1)%len(createFields)])
+				return m, nil
+			}
+		case key.Matches(msg, default_form_keys.Toggle):
+			if m.focused == NEW_SESSION_ATTACH {
+				m.create_attach = !m.create_attach
+				return m, nil
+			}
+		}
+	}
+	if m.focused == NEW_SESSION_ATTACH {
+		return m, nil
+	}
+
+	m.inputs[m.focused], cmd = m.inputs[m.focused].Update(msg)
+	switch m.focused {
+	case NEW_SESSION_INPUT:
+		m.sanitizeInput()
+	case NEW_SESSION_DIR_INPUT:
+		m.inputs[m.focused].SetSuggestions(completeDir(m.inputs[m.focused].Value()))
+	}
+	return m, cmd
+}
+
+// createSession creates the session described by the form, switching to it
+// when asked to. An invalid field gets the focus instead.
+func (m model) createSession() (tea.Model, tea.Cmd) {
+	for _, field := range createFields {
+		if m.validateInput(field) != nil {
+			m.focusField(field)
+			return m, nil
+		}
+	}
+	opts := CreateSessionOptions{
+		Name:    m.inputs[NEW_SESSION_INPUT].Value(),
+		Dir:     expandHome(m.inputs[NEW_SESSION_DIR_INPUT].Value()),
+		Command: m.inputs[NEW_SESSION_COMMAND_INPUT].Value(),
+	}
+	m.err = m.tmux.TmuxCreateSession(opts)
+	if m.err != nil {
+		return m, nil
+	}
+
+	attach := m.create_attach
+	m.state = MANAGE_STATE
+	m.resetCreateForm()
+	if attach {
+		m.err = m.tmux.TmuxSwitchSession(opts.Name)
+		if m.err == nil {
+			return m, tea.Quit
+		}
+	}
+	// The session exists now even though we may not have got to it.
+	err := m.err
+	m.reloadSessions()
+	m.err = err
+	return m, m.requestPreview()
+}
+
+func (m model) viewCreateState() string {
+	label := func(field Input, text string) string {
+		if m.focused == field {
+			return m.styles.label.Inherit(m.styles.selected).Render(text)
+		}
+		return m.styles.label.Render(text)
+	}
+	attach := "[ ]"
+	if m.create_attach {
+		attach = "[x]"
+	}
+
+	// Fields are only judged once something has been typed in them.
+	var invalid []string
+	for _, field := range []Input{NEW_SESSION_INPUT, NEW_SESSION_DIR_INPUT} {
+		if len(m.inputs[field].Value()) == 0 {
+			continue
+		}
+		if err := m.validateInput(field); err != nil {
+			invalid = append(invalid, err.Error())
+		}
+	}
+	var status string
+	if len(invalid) > 0 {
+		status = m.styles.renderStatus(errors.New(strings.Join(invalid, "\n")))
+	}
+
+	return m.styles.root.Render(
+		fmt.Sprintf(
+			"%s\n%s%s%s\n%s",
+			m.styles.header.Render("Create session:"),
+			m.styles.form.Render(strings.Join([]string{
+				label(NEW_SESSION_INPUT, "Name") + m.inputs[NEW_SESSION_INPUT].View(),
+				label(NEW_SESSION_DIR_INPUT, "Directory") + m.inputs[NEW_SESSION_DIR_INPUT].View(),
+				label(NEW_SESSION_COMMAND_INPUT, "Command") + m.inputs[NEW_SESSION_COMMAND_INPUT].View(),
+				label(NEW_SESSION_ATTACH, "Attach") + attach,
+			}, "\n")),
+			status,
+			m.styles.renderStatus(m.err),
+			m.styles.help.Render(fmt.Sprintf(
+				"%s next field, %s toggle, %s create",
+				default_form_keys.Next.Help().Key,
+				default_form_keys.Toggle.Help().Key,
+				default_form_keys.Submit.Help().Key,
+			)),
+		),
+	)
+}
+
+// StartDefaultSession starts a session and switches to it when tsm runs
+// outside of tmux with no session to pick from, which attaches to it once
+// tsm exits. The session is called name, or after the current directory
+// when name is empty. started reports whether a session was started.
+func StartDefaultSession(tmux Tmuxer, name string) (started bool, err error) {
+	if insideTmux() {
+		return false, nil
+	}
+	sessions, err := tmux.TmuxListSessions()
+	if err != nil || len(sessions) > 0 {
+		return false, err
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return false, err
+	}
+	if len(name) == 0 {
+		name = filepath.Base(dir)
+	}
+	name = SessionNameFor(name)
+	if err := tmux.TmuxCreateSession(CreateSessionOptions{Name: name, Dir: dir}); err != nil {
+		return false, err
+	}
+	return true, tmux.TmuxSwitchSession(name)
+}
@@ -0,0 +1,129 @@
+package tsm
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func testDirs(t *testing.T) string {
+	root := t.TempDir()
+	for _, dir := range []string{"alpha", "beta", ".hidden"} {
+		if err := os.Mkdir(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, "afile"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestCompleteDir(t *testing.T) {
+	root := testDirs(t)
+	tests := []struct {
+		value    string
+		expected []string
+	}{
+		{root + "/a", []string{root + "/alpha/"}},
+		{root + "/", []string{root + "/alpha/", root + "/beta/"}},
+		{root + "/.", []string{root + "/.hidden/"}},
+		{root + "/missing/", nil},
+	}
+
+	for _, test := range tests {
+		if dirs := completeDir(test.value); !slices.Equal(dirs, test.expected) {
+			t.Errorf("Expected %s to complete to %v, got %v", test.value, test.expected, dirs)
+		}
+	}
+}
+
+func TestCreateSessionForm(t *testing.T) {
+	root := testDirs(t)
+	tmux := &MockTmux{}
+	test_model := InitialSessionModel(tmux, Config{})
+
+	updModel, cmd := sendKeys(test_model, "c", "work", "tab", root, "tab", "htop", "tab", " ", "enter")
+	expected := CreateSessionOptions{Name: "work", Dir: root, Command: "htop"}
+	if len(tmux.created) != 1 || tmux.created[0] != expected {
+		t.Fatalf("Expected %v to be created, got %v", expected, tmux.created)
+	}
+	if tmux.active_session != "work" {
+		t.Errorf("Expected to switch to work, got %s", tmux.active_session)
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Errorf("Expected cmd to be tea.Quit, got %v", cmd())
+	}
+	if updModel.(model).create_attach || len(updModel.(model).inputs[NEW_SESSION_INPUT].Value()) > 0 {
+		t.Errorf("Expected the form to be reset")
+	}
+}
+
+func TestCreateSessionFormCompletesDir(t *testing.T) {
+	root := testDirs(t)
+	test_model := InitialSessionModel(&MockTmux{}, Config{})
+
+	updModel, _ := sendKeys(test_model, "c", "tab", root+"/al", "tab")
+	if value := updModel.(model).inputs[NEW_SESSION_DIR_INPUT].Value(); value != root+"/alpha/" {
+		t.Errorf("Expected the directory to be completed, got %s", value)
+	}
+	if focused := updModel.(model).focused; focused != NEW_SESSION_DIR_INPUT {
+		t.Errorf("Expected completing to keep the directory focused, got %d", focused)
+	}
+
+	updModel, _ = sendKeys(updModel, "tab")
+	if focused := updModel.(model).focused; focused != NEW_SESSION_COMMAND_INPUT {
+		t.Errorf("Expected tab to move on once completed, got %d", focused)
+	}
+}
+
+func TestCreateSessionFormRejectsMissingDir(t *testing.T) {
+	tmux := &MockTmux{}
+	test_model := InitialSessionModel(tmux, Config{})
+
+	updModel, _ := sendKeys(test_model, "c", "work", "tab", "/no/such/dir", "shift+tab", "enter")
+	if len(tmux.created) > 0 {
+		t.Errorf("Expected nothing to be created, got %v", tmux.created)
+	}
+	if m := updModel.(model); m.state != CREATE_STATE || m.focused != NEW_SESSION_DIR_INPUT {
+		t.Errorf("Expected the directory to be focused, got state %d and field %d", m.state, m.focused)
+	}
+}
+
+func TestStartDefaultSession(t *testing.T) {
+	t.Setenv("TMUX", "")
+	tmux := &MockTmux{}
+	started, err := StartDefaultSession(tmux, "work.main")
+	if err != nil || !started {
+		t.Fatalf("Expected a session to be started, got %v, %v", started, err)
+	}
+	if len(tmux.created) != 1 || tmux.created[0].Name != "work_main" || tmux.active_session != "work_main" {
+		t.Errorf("Expected work_main to be started and switched to, got %v on %q", tmux.created, tmux.active_session)
+	}
+
+	dir, _ := os.Getwd()
+	tmux = &MockTmux{}
+	if _, err := StartDefaultSession(tmux, ""); err != nil {
+		t.Fatal(err)
+	}
+	if name := SessionNameFor(filepath.Base(dir)); tmux.active_session != name || tmux.created[0].Dir != dir {
+		t.Errorf("Expected %s to be started in %s, got %v", name, dir, tmux.created)
+	}
+}
+
+func TestStartDefaultSessionLeavesServersWithSessions(t *testing.T) {
+	t.Setenv("TMUX", "")
+	tmux := &MockTmux{sessions: testSessions("main")}
+	if started, err := StartDefaultSession(tmux, ""); started || err != nil || len(tmux.created) > 0 {
+		t.Errorf("Expected nothing to be started next to main, got %v, %v, %v", started, err, tmux.created)
+	}
+
+	t.Setenv("TMUX", "/tmp/tmux-0/default,1,0")
+	tmux = &MockTmux{}
+	if started, _ := StartDefaultSession(tmux, ""); started {
+		t.Errorf("Expected nothing to be started inside tmux")
+	}
+}
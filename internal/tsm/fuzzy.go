@@ -0,0 +1,124 @@
+package tsm
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Scoring follows fzf: every matched character is worth the same, characters
+// at word boundaries and runs of consecutive characters earn a bonus and gaps
+// between matches cost a little.
+const (
+	scoreMatch        = 16
+	scoreGapStart     = -3
+	scoreGapExtension = -1
+
+	bonusBoundary    = scoreMatch / 2
+	bonusCamel       = bonusBoundary - 1
+	bonusConsecutive = -(scoreGapStart + scoreGapExtension)
+
+	bonusFirstCharMultiplier = 2
+)
+
+// fuzzyMatch reports whether pattern is a subsequence of text, how well it
+// matches and at which rune positions. Matching is case insensitive unless
+// the pattern contains an upper case letter. Of all possible alignments the
+// one with the highest score wins, so "foo" in "f-o-foo" highlights the
+// trailing run rather than the scattered first letters.
+func fuzzyMatch(pattern string, text string) (int, []int, bool) {
+	if len(pattern) == 0 {
+		return 0, nil, true
+	}
+
+	caseSensitive := strings.ToLower(pattern) != pattern
+	fold := func(r rune) rune {
+		if caseSensitive {
+			return r
+		}
+		return unicode.ToLower(r)
+	}
+	p := []rune(pattern)
+	t := []rune(text)
+	if len(p) > len(t) {
+		return 0, nil, false
+	}
+
+	// cell holds the best way to end the i-th pattern rune on the j-th text
+	// rune. chunkBonus is the bonus of the first rune of the consecutive run
+	// the match is part of, which the rest of the run inherits.
+	type cell struct {
+		score      int
+		chunkBonus int
+		prev       int
+		ok         bool
+	}
+	cells := make([][]cell, len(p))
+	for i := range p {
+		cells[i] = make([]cell, len(t))
+		for j := range t {
+			if fold(t[j]) != fold(p[i]) {
+				continue
+			}
+			bonus := boundaryBonus(t, j)
+			if i == 0 {
+				cells[i][j] = cell{score: scoreMatch + bonus*bonusFirstCharMultiplier, chunkBonus: bonus, prev: -1, ok: true}
+				continue
+			}
+			for k := i - 1; k < j; k++ {
+				prev := cells[i-1][k]
+				if !prev.ok {
+					continue
+				}
+				candidate := cell{prev: k, ok: true}
+				if gap := j - k - 1; gap == 0 {
+					candidate.chunkBonus = prev.chunkBonus
+					candidate.score = prev.score + scoreMatch + max(bonus, prev.chunkBonus, bonusConsecutive)
+				} else {
+					candidate.chunkBonus = bonus
+					candidate.score = prev.score + scoreGapStart + scoreGapExtension*(gap-1) + scoreMatch + bonus
+				}
+				if !cells[i][j].ok || candidate.score > cells[i][j].score {
+					cells[i][j] = candidate
+				}
+			}
+		}
+	}
+
+	last := cells[len(p)-1]
+	end := -1
+	for j := range last {
+		if last[j].ok && (end < 0 || last[j].score > last[end].score) {
+			end = j
+		}
+	}
+	if end < 0 {
+		return 0, nil, false
+	}
+
+	positions := make([]int, len(p))
+	for i, j := len(p)-1, end; i >= 0; i-- {
+		positions[i] = j
+		j = cells[i][j].prev
+	}
+
+	return last[end].score, positions, true
+}
+
+func boundaryBonus(text []rune, i int) int {
+	if i == 0 {
+		return bonusBoundary
+	}
+	prev, cur := text[i-1], text[i]
+	switch {
+	case !isWordRune(prev) && isWordRune(cur):
+		return bonusBoundary
+	case unicode.IsLower(prev) && unicode.IsUpper(cur),
+		unicode.IsLetter(prev) && unicode.IsDigit(cur):
+		return bonusCamel
+	}
+	return 0
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,53 @@
+package tsm
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		pattern           string
+		text              string
+		expected_ok       bool
+		expected_position []int
+	}{
+		{"", "anything", true, nil},
+		{"tsm", "tmux-session-manager", true, []int{0, 5, 13}},
+		{"abc", "ab", false, nil},
+		{"ABC", "abc", false, nil},
+		{"abc", "ABC", true, []int{0, 1, 2}},
+		{"foo", "f-o-foo", true, []int{4, 5, 6}},
+	}
+
+	for _, test := range tests {
+		_, positions, ok := fuzzyMatch(test.pattern, test.text)
+		if ok != test.expected_ok {
+			t.Errorf("Expected match of %q in %q to be %v", test.pattern, test.text, test.expected_ok)
+		}
+		if !slices.Equal(positions, test.expected_position) {
+			t.Errorf("Expected positions of %q in %q to be %v, got %v", test.pattern, test.text, test.expected_position, positions)
+		}
+	}
+}
+
+func TestFuzzyScorePrefersBoundariesAndConsecutiveRuns(t *testing.T) {
+	tests := []struct {
+		pattern string
+		better  string
+		worse   string
+	}{
+		{"api", "api", "a-p-i"},
+		{"ws", "web-server", "towns"},
+		{"nv", "neovim", "conv"},
+		{"cfg", "myConfigGen", "xcxfxg"},
+	}
+
+	for _, test := range tests {
+		better, _, _ := fuzzyMatch(test.pattern, test.better)
+		worse, _, _ := fuzzyMatch(test.pattern, test.worse)
+		if better <= worse {
+			t.Errorf("Expected %q to score higher than %q for %q, got %d <= %d", test.better, test.worse, test.pattern, better, worse)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package tsm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// newHelp is the key help of a model, drawn in the colors of the theme.
+func newHelp(s Styles) help.Model {
+	h := help.New()
+	h.Styles = help.Styles{
+		Ellipsis:       s.dim,
+		ShortKey:       s.help,
+		ShortDesc:      s.dim,
+		ShortSeparator: s.dim,
+		FullKey:        s.help,
+		FullDesc:       s.dim,
+		FullSeparator:  s.dim,
+	}
+	return h
+}
+
+// formKeyMap drives the text inputs of every model.
+type formKeyMap struct {
+	Submit key.Binding
+	Cancel key.Binding
+	Next   key.Binding
+	Prev   key.Binding
+	Toggle key.Binding
+}
+
+var default_form_keys = formKeyMap{
+	Submit: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "submit"),
+	),
+	Cancel: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "cancel"),
+	),
+	Next: key.NewBinding(
+		key.WithKeys("tab"),
+		key.WithHelp("tab", "next field"),
+	),
+	Prev: key.NewBinding(
+		key.WithKeys("shift+tab"),
+		key.WithHelp("shift+tab", "previous field"),
+	),
+	Toggle: key.NewBinding(
+		key.WithKeys(" "),
+		key.WithHelp("space", "toggle"),
+	),
+}
+
+// confirmKeyMap answers the kill confirmation.
+type confirmKeyMap struct {
+	Yes  key.Binding
+	No   key.Binding
+	Quit key.Binding
+}
+
+var default_confirm_keys = confirmKeyMap{
+	Yes: key.NewBinding(
+		key.WithKeys("y", "Y"),
+		key.WithHelp("y", "kill"),
+	),
+	No: key.NewBinding(
+		key.WithKeys("n", "N", "esc", "q"),
+		key.WithHelp("n/esc", "cancel"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c"),
+		key.WithHelp("ctrl+c", "quit"),
+	),
+}
+
+// closeHelp dismisses the key overlay on any key, reporting whether it was
+// open so the key does nothing else.
+func closeHelp(h *help.Model, msg tea.Msg) bool {
+	if _, ok := msg.(tea.KeyMsg); !ok || !h.ShowAll {
+		return false
+	}
+	h.ShowAll = false
+	return true
+}
+
+// viewKeyOverlay lists every binding of km as it is bound right now, so it
+// follows whatever the config rebound.
+func (s Styles) viewKeyOverlay(km help.KeyMap) string {
+	var groups []string
+	for _, group := range km.FullHelp() {
+		var lines []string
+		for _, binding := range group {
+			if !binding.Enabled() {
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("%s %s", s.selected.Width(12).Render(binding.Help().Key), binding.Help().Desc))
+		}
+		groups = append(groups, strings.Join(lines, "\n"))
+	}
+
+	return s.root.Render(
+		fmt.Sprintf(
+			"%s\n%s\n%s",
+			s.header.Render("Keys:"),
+			s.list.Render(lipgloss.NewStyle().Padding(0, 1).Render(strings.Join(groups, "\n\n"))),
+			s.help.Render("any key to close"),
+		),
+	)
+}
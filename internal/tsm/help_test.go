@@ -0,0 +1,46 @@
+package tsm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKeyOverlayFollowsReboundKeys(t *testing.T) {
+	test_model := InitialSessionModel(&MockTmux{sessions: testSessions("main")}, Config{Keys: map[string][]string{"help": {"H"}, "kill": {"D"}}})
+
+	updModel, _ := sendKeys(test_model, "?")
+	if updModel.(model).help.ShowAll {
+		t.Errorf("Expected ? to do nothing once help is rebound")
+	}
+	updModel, _ = sendKeys(updModel, "H")
+	view := updModel.View()
+	for _, expected := range []string{"Keys:", "D", "kill", "mark range"} {
+		if !strings.Contains(view, expected) {
+			t.Errorf("Expected overlay to mention %q, got %s", expected, view)
+		}
+	}
+
+	// The key that closes the overlay does nothing else.
+	updModel, _ = sendKeys(updModel, "D")
+	if m := updModel.(model); m.help.ShowAll || m.state != MANAGE_STATE {
+		t.Errorf("Expected any key to close the overlay only, got state %d", m.state)
+	}
+}
+
+func TestWindowKeyOverlay(t *testing.T) {
+	updModel, _ := sendKeys(newTestWindowModel(), "?")
+	if view := updModel.View(); !strings.Contains(view, "swap up") {
+		t.Errorf("Expected overlay to list the window keys, got %s", view)
+	}
+	updModel, _ = sendKeys(updModel, "j")
+	if m := updModel.(windowModel); m.help.ShowAll || m.cursor != 0 {
+		t.Errorf("Expected j to only close the overlay, got cursor %d", m.cursor)
+	}
+}
+
+func TestConfirmHelpFollowsUndoKey(t *testing.T) {
+	updModel, _ := sendKeys(newTestConfirmModel(Config{Keys: map[string][]string{"undo": {"U"}}}), "d")
+	if view := updModel.View(); !strings.Contains(view, "U brings them back") {
+		t.Errorf("Expected the confirm dialog to name the undo key, got %s", view)
+	}
+}
@@ -0,0 +1,253 @@
+package tsm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// maxHistoryRank caps the ranks of all sessions together. Past it every rank
+// is scaled down so sessions that are no longer used fade out of the history.
+const maxHistoryRank = 1000
+
+// historyRepeat is how close together two records of the same session are
+// taken for one switch, seen by both tsm and the tmux hook.
+const historyRepeat = 2 * time.Second
+
+// ErrNoPreviousSession is returned when no other running session was used
+// before the current one.
+var ErrNoPreviousSession = errors.New("no previous session")
+
+// HistoryVersion is bumped whenever the history format changes in a way
+// older versions of tsm cannot read.
+const HistoryVersion = 1
+
+// History remembers which sessions were switched to and how often, to rank
+// them by frecency the way zoxide ranks directories.
+type History struct {
+	Version int            `json:"version"`
+	Entries []HistoryEntry `json:"entries"`
+}
+
+type HistoryEntry struct {
+	Session    string    `json:"session"`
+	Rank       float64   `json:"rank"`
+	LastAccess time.Time `json:"last_access"`
+}
+
+// DefaultHistoryPath is where the history lives unless told otherwise.
+func DefaultHistoryPath() string {
+	return filepath.Join(stateDir(), "history.json")
+}
+
+// LoadHistory reads the history at path. A missing file or an empty path is
+// an empty history.
+func LoadHistory(path string) (History, error) {
+	history := History{Version: HistoryVersion}
+	if len(path) == 0 {
+		return history, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return history, nil
+	}
+	if err != nil {
+		return history, err
+	}
+	if err := json.Unmarshal(data, &history); err != nil {
+		return history, fmt.Errorf("%s: %w", path, err)
+	}
+	if history.Version > HistoryVersion {
+		return History{Version: HistoryVersion}, fmt.Errorf("%s: history version %d is newer than this tsm understands", path, history.Version)
+	}
+	return history, nil
+}
+
+// SaveHistory writes the history to path, or does nothing when path is
+// empty.
+func SaveHistory(path string, history History) error {
+	if len(path) == 0 {
+		return nil
+	}
+	history.Version = HistoryVersion
+	return writeJSON(path, history)
+}
+
+// RecordSwitch adds a switch from one session to another to the history at
+// path. from only has its last access bumped, so it is the previous session
+// next time, and may be empty when it is not known.
+func RecordSwitch(path string, from string, to string) error {
+	history, err := LoadHistory(path)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if len(from) > 0 && from != to {
+		// Left just before to was reached, so the two never tie.
+		history.Touch(from, now.Add(-time.Millisecond))
+	}
+	history.Record(to, now)
+	return SaveHistory(path, history)
+}
+
+func (h *History) entry(session string) *HistoryEntry {
+	i := slices.IndexFunc(h.Entries, func(e HistoryEntry) bool { return e.Session == session })
+	if i < 0 {
+		h.Entries = append(h.Entries, HistoryEntry{Session: session})
+		i = len(h.Entries) - 1
+	}
+	return &h.Entries[i]
+}
+
+// Record counts a switch to session at now.
+func (h *History) Record(session string, now time.Time) {
+	entry := h.entry(session)
+	if now.Sub(entry.LastAccess) >= historyRepeat {
+		entry.Rank++
+	}
+	entry.LastAccess = now
+	h.age()
+}
+
+// Touch marks session as used at now without counting a switch to it.
+func (h *History) Touch(session string, now time.Time) {
+	h.entry(session).LastAccess = now
+}
+
+// Rename moves the history of a session to its new name.
+func (h *History) Rename(oldSession string, session string) {
+	h.Entries = slices.DeleteFunc(h.Entries, func(e HistoryEntry) bool { return e.Session == session })
+	for i := range h.Entries {
+		if h.Entries[i].Session == oldSession {
+			h.Entries[i].Session = session
+		}
+	}
+}
+
+// age scales every rank down once they add up to more than maxHistoryRank
+// and forgets the sessions that drop below one.
+func (h *History) age() {
+	var total float64
+	for _, entry := range h.Entries {
+		total += entry.Rank
+	}
+	if total <= maxHistoryRank {
+		return
+	}
+	factor := 0.9 * maxHistoryRank / total
+	for i := range h.Entries {
+		h.Entries[i].Rank *= factor
+	}
+	h.Entries = slices.DeleteFunc(h.Entries, func(e HistoryEntry) bool { return e.Rank < 1 })
+}
+
+// Score is the frecency of session at now: how often it was switched to,
+// weighted by how long ago the last switch was.
+func (h History) Score(session string, now time.Time) float64 {
+	i := slices.IndexFunc(h.Entries, func(e HistoryEntry) bool { return e.Session == session })
+	if i < 0 {
+		return 0
+	}
+	entry := h.Entries[i]
+	switch age := now.Sub(entry.LastAccess); {
+	case age < time.Hour:
+		return entry.Rank * 4
+	case age < 24*time.Hour:
+		return entry.Rank * 2
+	case age < 7*24*time.Hour:
+		return entry.Rank / 2
+	}
+	return entry.Rank / 4
+}
+
+// Previous is the session used last before current.
+func (h History) Previous(current string) string {
+	var previous HistoryEntry
+	for _, entry := range h.Entries {
+		if entry.Session != current && entry.LastAccess.After(previous.LastAccess) {
+			previous = entry
+		}
+	}
+	return previous.Session
+}
+
+// SwitchToPrevious switches to the running session used last before the
+// current one, like switch-client -l but going by the history of tsm.
+func SwitchToPrevious(tmux Tmuxer, history History) error {
+	current, err := tmux.TmuxCurrentSession()
+	if err != nil {
+		return err
+	}
+	sessions, err := tmux.TmuxListSessions()
+	if err != nil {
+		return err
+	}
+	history.Entries = slices.DeleteFunc(slices.Clone(history.Entries), func(e HistoryEntry) bool {
+		return !slices.ContainsFunc(sessions, func(s Session) bool { return s.Name == e.Session })
+	})
+	previous := history.Previous(current)
+	if len(previous) == 0 {
+		return ErrNoPreviousSession
+	}
+	return tmux.TmuxSwitchSession(previous)
+}
+
+// frecencyLead are the current and previous sessions when frecency put
+// them first in the list, nothing otherwise.
+func (m model) frecencyLead() []string {
+	if m.config.SortOrder != SORT_FRECENCY || len(m.sessions) < 2 || m.sessions[0].Name != m.current {
+		return nil
+	}
+	return []string{m.sessions[0].Name, m.sessions[1].Name}
+}
+
+// previousAfterCurrent moves the previous session of frecency right after
+// the current one, wherever the pins and the tag groups left them.
+func (m model) previousAfterCurrent(all []choice) []choice {
+	lead := m.frecencyLead()
+	if len(lead) == 0 {
+		return all
+	}
+	session := func(name string) func(choice) bool {
+		return func(c choice) bool { return c.kind == SESSION_CHOICE && c.session.Name == name }
+	}
+	previous := slices.IndexFunc(all, session(lead[1]))
+	if previous < 0 || !slices.ContainsFunc(all, session(lead[0])) {
+		return all
+	}
+	c := all[previous]
+	all = slices.Delete(slices.Clone(all), previous, previous+1)
+	return slices.Insert(all, slices.IndexFunc(all, session(lead[0]))+1, c)
+}
+
+// sortByFrecency puts current first and the session used before it second,
+// so switching back is one key away, then the rest by score.
+func (h History) sortByFrecency(sessions []Session, current string, now time.Time) {
+	previous := h.Previous(current)
+	place := func(s Session) int {
+		switch s.Name {
+		case current:
+			return 0
+		case previous:
+			return 1
+		}
+		return 2
+	}
+	slices.SortStableFunc(sessions, func(a, b Session) int {
+		if place(a) != place(b) {
+			return place(a) - place(b)
+		}
+		scoreA, scoreB := h.Score(a.Name, now), h.Score(b.Name, now)
+		switch {
+		case scoreA > scoreB:
+			return -1
+		case scoreA < scoreB:
+			return 1
+		}
+		return 0
+	})
+}
@@ -0,0 +1,148 @@
+package tsm
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestHistoryScore(t *testing.T) {
+	now := time.Now()
+	var history History
+	history.Record("old", now.Add(-30*24*time.Hour))
+	history.Record("old", now.Add(-29*24*time.Hour))
+	history.Record("old", now.Add(-28*24*time.Hour))
+	history.Record("recent", now.Add(-time.Minute))
+
+	if old, recent := history.Score("old", now), history.Score("recent", now); old >= recent {
+		t.Errorf("Expected one recent switch to beat three old ones, got %v and %v", old, recent)
+	}
+	if score := history.Score("never", now); score != 0 {
+		t.Errorf("Expected no score for a session never switched to, got %v", score)
+	}
+}
+
+func TestHistoryCountsRepeatedRecordsOnce(t *testing.T) {
+	now := time.Now()
+	var history History
+	history.Record("work", now)
+	history.Record("work", now.Add(time.Second))
+	if history.Entries[0].Rank != 1 {
+		t.Errorf("Expected a switch seen by tsm and the hook to count once, got %v", history.Entries[0].Rank)
+	}
+}
+
+func TestHistoryAges(t *testing.T) {
+	now := time.Now()
+	history := History{Entries: []HistoryEntry{
+		{Session: "busy", Rank: maxHistoryRank, LastAccess: now},
+		{Session: "forgotten", Rank: 1, LastAccess: now.Add(-time.Hour)},
+	}}
+	history.Record("busy", now.Add(time.Minute))
+
+	if len(history.Entries) != 1 || history.Entries[0].Session != "busy" {
+		t.Fatalf("Expected the forgotten session to fade out, got %v", history.Entries)
+	}
+	if rank := history.Entries[0].Rank; rank > maxHistoryRank {
+		t.Errorf("Expected the ranks to be scaled down, got %v", rank)
+	}
+}
+
+func TestRecordSwitch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	if err := RecordSwitch(path, "", "work"); err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordSwitch(path, "work", "notes"); err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordSwitch(path, "", "scratch"); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := LoadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if previous := history.Previous("scratch"); previous != "notes" {
+		t.Errorf("Expected notes before scratch, got %s", previous)
+	}
+	history.Rename("notes", "docs")
+	if previous := history.Previous("scratch"); previous != "docs" {
+		t.Errorf("Expected the history to follow the rename, got %s", previous)
+	}
+}
+
+func TestSwitchToPrevious(t *testing.T) {
+	now := time.Now()
+	var history History
+	history.Record("gone", now.Add(-time.Minute))
+	history.Record("api", now.Add(-time.Hour))
+	history.Record("main", now)
+	tmux := &MockTmux{sessions: testSessions("main", "api"), active_session: "main"}
+
+	// gone was used last but is not running any more.
+	if err := SwitchToPrevious(tmux, history); err != nil {
+		t.Fatal(err)
+	}
+	if tmux.active_session != "api" {
+		t.Errorf("Expected a switch to api, got %s", tmux.active_session)
+	}
+
+	tmux = &MockTmux{sessions: testSessions("main"), active_session: "main"}
+	if err := SwitchToPrevious(tmux, history); !errors.Is(err, ErrNoPreviousSession) {
+		t.Errorf("Expected ErrNoPreviousSession, got %v", err)
+	}
+}
+
+func TestFrecencyKeepsPreviousSessionSecond(t *testing.T) {
+	now := time.Now()
+	history := History{Entries: []HistoryEntry{
+		{Session: "favourite", Rank: 50, LastAccess: now.Add(-10 * time.Minute)},
+		{Session: "main", Rank: 10, LastAccess: now.Add(-time.Minute)},
+		{Session: "last", Rank: 1, LastAccess: now.Add(-2 * time.Minute)},
+	}}
+	sessions := []Session{{Name: "fresh"}, {Name: "favourite"}, {Name: "last"}, {Name: "main"}}
+
+	SortSessions(sessions, SORT_FRECENCY, history, "main")
+	expected := []string{"main", "last", "favourite", "fresh"}
+	if names := sessionNames(sessions); !slices.Equal(names, expected) {
+		t.Errorf("Expected %v, got %v", expected, names)
+	}
+}
+
+func TestEnterGoesBackToThePreviousSession(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	for _, session := range []string{"logs", "main"} {
+		if err := RecordSwitch(path, "", session); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mockTmux := &MockTmux{
+		sessions:       []Session{{Name: "logs"}, {Name: "notes"}, {Name: "main"}},
+		active_session: "main",
+	}
+	m := InitialSessionModel(mockTmux, Config{SortOrder: SORT_FRECENCY, HistoryPath: path})
+
+	sendKeys(m, "enter")
+	if mockTmux.active_session != "logs" {
+		t.Errorf("Expected enter to go back to logs, got %s", mockTmux.active_session)
+	}
+}
+
+func TestNewerHistoryIsLeftAlone(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	newer := []byte(`{"version": 99, "entries": []}`)
+	if err := os.WriteFile(path, newer, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordSwitch(path, "a", "b"); err == nil {
+		t.Errorf("Expected an error for a newer history")
+	}
+	if data, _ := os.ReadFile(path); string(data) != string(newer) {
+		t.Errorf("Expected the newer history not to be written over, got %s", data)
+	}
+}
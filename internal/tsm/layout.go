@@ -0,0 +1,291 @@
+package tsm
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"gopkg.in/yaml.v3"
+)
+
+// LayoutFileName is the layout a repository can ship for its own session.
+const LayoutFileName = ".tsm.yml"
+
+// Layout describes a whole session the way tmuxinator does: where it lives,
+// its environment, its windows and what runs in every pane.
+type Layout struct {
+	Name        string            `yaml:"name"`
+	Root        string            `yaml:"root"`
+	Environment map[string]string `yaml:"environment"`
+	Pre         []string          `yaml:"pre"`
+	Post        []string          `yaml:"post"`
+	Windows     []LayoutWindow    `yaml:"windows"`
+}
+
+type LayoutWindow struct {
+	Name   string       `yaml:"name"`
+	Root   string       `yaml:"root"`
+	Layout string       `yaml:"layout"`
+	Panes  []LayoutPane `yaml:"panes"`
+}
+
+type LayoutPane struct {
+	Command string `yaml:"command"`
+	Root    string `yaml:"root"`
+}
+
+// UnmarshalYAML lets a pane be written as a bare command.
+func (p *LayoutPane) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&p.Command)
+	}
+	type plain LayoutPane
+	return node.Decode((*plain)(p))
+}
+
+// LoadLayout reads a layout file. The session is named after the folder the
+// file is in unless it says otherwise, and relative roots are resolved
+// against that folder too.
+func LoadLayout(path string) (Layout, error) {
+	var layout Layout
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return layout, err
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&layout); err != nil {
+		return layout, fmt.Errorf("%s: %w", path, err)
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return layout, err
+	}
+	if len(layout.Name) == 0 {
+		layout.Name = filepath.Base(dir)
+	}
+	layout.Name = SessionNameFor(layout.Name)
+	layout.Root = resolveRoot(dir, layout.Root)
+	for i, window := range layout.Windows {
+		if len(window.Name) == 0 {
+			return layout, fmt.Errorf("%s: window %d has no name", path, i+1)
+		}
+		layout.Windows[i].Root = resolveRoot(layout.Root, window.Root)
+		for j, pane := range window.Panes {
+			layout.Windows[i].Panes[j].Root = resolveRoot(layout.Windows[i].Root, pane.Root)
+		}
+	}
+
+	return layout, nil
+}
+
+func resolveRoot(base string, root string) string {
+	root = expandHome(root)
+	if len(root) == 0 {
+		return base
+	}
+	if filepath.IsAbs(root) {
+		return root
+	}
+	return filepath.Join(base, root)
+}
+
+// ApplyLayout builds the session described by layout. It is safe to run
+// against a session that already exists: windows are matched by name and
+// only the missing ones are created. Pre and post hooks only run when there
+// is something to create.
+func ApplyLayout(tmux Tmuxer, layout Layout) error {
+	sessions, err := tmux.TmuxListSessions()
+	if err != nil {
+		return err
+	}
+	created := !slices.ContainsFunc(sessions, func(s Session) bool { return s.Name == layout.Name })
+	var existing []Window
+	if !created {
+		if existing, err = tmux.TmuxListWindows(layout.Name); err != nil {
+			return err
+		}
+	}
+	missing := slices.DeleteFunc(slices.Clone(layout.Windows), func(window LayoutWindow) bool {
+		return slices.ContainsFunc(existing, func(w Window) bool { return w.Name == window.Name })
+	})
+	build := created || len(missing) > 0
+
+	if build {
+		if err := runHooks(layout.Root, layout.Pre); err != nil {
+			return err
+		}
+	}
+	if created {
+		if err := tmux.TmuxCreateSession(CreateSessionOptions{Name: layout.Name, Dir: layout.Root}); err != nil {
+			return err
+		}
+	}
+	for _, name := range sortedKeys(layout.Environment) {
+		if err := tmux.TmuxSetEnvironment(layout.Name, name, layout.Environment[name]); err != nil {
+			return err
+		}
+	}
+
+	// A new session comes with a window already, it is used for the first
+	// one rather than matched by name.
+	var first string
+	if created {
+		windows, err := tmux.TmuxListWindows(layout.Name)
+		if err != nil {
+			return err
+		}
+		if len(windows) > 0 {
+			first = windows[0].Id
+		}
+	}
+	for i, window := range missing {
+		var id string
+		if i == 0 && len(first) > 0 {
+			// Its shell started before the environment was set, so it is
+			// restarted when there is any.
+			id = first
+			if err := tmux.TmuxRenameWindow(id, window.Name); err != nil {
+				return err
+			}
+			if len(layout.Environment) > 0 || window.Root != layout.Root {
+				if err := respawnFirstPane(tmux, id, window.Root); err != nil {
+					return err
+				}
+			}
+		} else {
+			id, err = tmux.TmuxCreateWindow(layout.Name, window.Name, window.Root)
+			if err != nil {
+				return err
+			}
+		}
+		if err := buildPanes(tmux, id, window); err != nil {
+			return err
+		}
+	}
+
+	if !build {
+		return nil
+	}
+	return runHooks(layout.Root, layout.Post)
+}
+
+func respawnFirstPane(tmux Tmuxer, window string, dir string) error {
+	panes, err := tmux.TmuxListPanes(window)
+	if err != nil {
+		return err
+	}
+	return tmux.TmuxRespawnPane(panes[0].Id, dir)
+}
+
+func buildPanes(tmux Tmuxer, window string, layout LayoutWindow) error {
+	panes, err := tmux.TmuxListPanes(window)
+	if err != nil {
+		return err
+	}
+	ids := []string{panes[0].Id}
+	for _, pane := range layout.Panes[min(1, len(layout.Panes)):] {
+		id, err := tmux.TmuxSplitPane(ids[len(ids)-1], true, pane.Root)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, id)
+		// Keep splitting from an evenly spread window so tmux does not run
+		// out of room before the final layout is applied.
+		if err := tmux.TmuxSelectLayout(window, "tiled"); err != nil {
+			return err
+		}
+	}
+
+	for i, pane := range layout.Panes {
+		if len(pane.Command) == 0 {
+			continue
+		}
+		if err := tmux.TmuxSendKeys(ids[i], pane.Command); err != nil {
+			return err
+		}
+	}
+
+	if len(layout.Layout) > 0 {
+		return tmux.TmuxSelectLayout(window, layout.Layout)
+	}
+	return nil
+}
+
+func runHooks(dir string, hooks []string) error {
+	for _, hook := range hooks {
+		cmd := exec.Command("sh", "-c", hook)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("hook %q failed: %w: %s", hook, err, strings.TrimSpace(string(out)))
+		}
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func hasLayout(project Project) bool {
+	_, err := os.Stat(filepath.Join(project.Path, LayoutFileName))
+	return err == nil
+}
+
+// describeLayout is what the preview shows for a layout that is not running.
+func describeLayout(layout Layout) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n", layout.Root)
+	for _, window := range layout.Windows {
+		fmt.Fprintf(&b, "%s (%d panes)\n", window.Name, max(len(window.Panes), 1))
+		for _, pane := range window.Panes {
+			if len(pane.Command) > 0 {
+				fmt.Fprintf(&b, "  $ %s\n", pane.Command)
+			}
+		}
+	}
+	b.WriteString("\nPress enter to build it.")
+	return b.String()
+}
+
+// layoutsMsg delivers the layout found in the working directory.
+type layoutsMsg struct {
+	layouts []Layout
+	err     error
+}
+
+// layoutAppliedMsg reports that a layout has been built and can be switched to.
+type layoutAppliedMsg struct {
+	session string
+	err     error
+}
+
+func discoverLayouts() tea.Cmd {
+	if _, err := os.Stat(LayoutFileName); err != nil {
+		return nil
+	}
+	return func() tea.Msg {
+		layout, err := LoadLayout(LayoutFileName)
+		if err != nil {
+			return layoutsMsg{err: err}
+		}
+		return layoutsMsg{layouts: []Layout{layout}}
+	}
+}
+
+func applyLayout(tmux Tmuxer, layout Layout) tea.Cmd {
+	return func() tea.Msg {
+		return layoutAppliedMsg{session: layout.Name, err: ApplyLayout(tmux, layout)}
+	}
+}
@@ -0,0 +1,174 @@
+package tsm
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const testLayout = `
+root: src
+environment:
+  APP_ENV: dev
+windows:
+  - name: editor
+    layout: main-vertical
+    panes:
+      - nvim .
+      - command: go test ./...
+        root: internal
+  - name: shell
+    root: /tmp
+`
+
+func writeLayout(t *testing.T, content string) string {
+	dir := filepath.Join(t.TempDir(), "my.project")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, LayoutFileName)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadLayout(t *testing.T) {
+	path := writeLayout(t, testLayout)
+	layout, err := LoadLayout(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	dir := filepath.Dir(path)
+	if layout.Name != "my_project" {
+		t.Errorf("Expected session to be named after the folder, got %s", layout.Name)
+	}
+	if layout.Root != filepath.Join(dir, "src") {
+		t.Errorf("Expected root %s, got %s", filepath.Join(dir, "src"), layout.Root)
+	}
+	editor := layout.Windows[0]
+	if editor.Root != layout.Root {
+		t.Errorf("Expected window to inherit the session root, got %s", editor.Root)
+	}
+	if editor.Panes[0].Command != "nvim ." || editor.Panes[0].Root != layout.Root {
+		t.Errorf("Expected bare pane command to be parsed, got %+v", editor.Panes[0])
+	}
+	if editor.Panes[1].Root != filepath.Join(layout.Root, "internal") {
+		t.Errorf("Expected pane root to be relative to its window, got %s", editor.Panes[1].Root)
+	}
+	if layout.Windows[1].Root != "/tmp" {
+		t.Errorf("Expected absolute window root to be kept, got %s", layout.Windows[1].Root)
+	}
+}
+
+func TestLoadLayoutRejectsBadFiles(t *testing.T) {
+	tests := []string{
+		"windows:\n  - name: editor\n    pane: [vim]\n",
+		"windows:\n  - layout: tiled\n",
+	}
+
+	for _, test := range tests {
+		if _, err := LoadLayout(writeLayout(t, test)); err == nil {
+			t.Errorf("Expected an error loading %q", test)
+		}
+	}
+}
+
+func TestApplyLayoutIsIdempotent(t *testing.T) {
+	layout, err := LoadLayout(writeLayout(t, testLayout))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmux := &MockTmux{
+		sessions:    testSessions("main"),
+		windows:     map[string][]Window{},
+		panes:       map[string][]Pane{},
+		environment: map[string]string{},
+		layouts:     map[string]string{},
+	}
+
+	if err := ApplyLayout(tmux, layout); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	windows := tmux.windows["my_project"]
+	if names := windowNames(windows); !slices.Equal(names, []string{"editor", "shell"}) {
+		t.Fatalf("Expected windows [editor shell], got %v", names)
+	}
+	if tmux.environment["my_project:APP_ENV"] != "dev" {
+		t.Errorf("Expected APP_ENV to be set, got %v", tmux.environment)
+	}
+	if len(tmux.respawned_panes) != 1 {
+		t.Errorf("Expected the first pane to be respawned with the environment, got %v", tmux.respawned_panes)
+	}
+	if len(tmux.panes[windows[0].Id]) != 2 {
+		t.Errorf("Expected editor to be split in two, got %v", tmux.panes[windows[0].Id])
+	}
+	if tmux.layouts[windows[0].Id] != "main-vertical" {
+		t.Errorf("Expected editor layout to be main-vertical, got %s", tmux.layouts[windows[0].Id])
+	}
+	if len(tmux.sent_keys) != 2 || !strings.HasSuffix(tmux.sent_keys[1], "go test ./...") {
+		t.Errorf("Expected pane commands to be sent, got %v", tmux.sent_keys)
+	}
+
+	tmux.TmuxKillWindow(windows[1].Id)
+	if err := ApplyLayout(tmux, layout); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if names := windowNames(tmux.windows["my_project"]); !slices.Equal(names, []string{"editor", "shell"}) {
+		t.Errorf("Expected only the missing window to be recreated, got %v", names)
+	}
+	if len(tmux.sent_keys) != 2 {
+		t.Errorf("Expected existing panes to be left alone, got %v", tmux.sent_keys)
+	}
+	if len(tmux.sessions) != 2 {
+		t.Errorf("Expected the session to be created once, got %v", tmux.sessions)
+	}
+}
+
+func TestApplyLayoutRunsHooksOnlyWhenBuilding(t *testing.T) {
+	hooks := filepath.Join(t.TempDir(), "hooks")
+	path := writeLayout(t, testLayout+`pre:
+  - echo pre >> `+hooks+`
+post:
+  - echo post >> `+hooks+`
+`)
+	// The hooks run in the root.
+	if err := os.Mkdir(filepath.Join(filepath.Dir(path), "src"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	layout, err := LoadLayout(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmux := &MockTmux{
+		windows:     map[string][]Window{},
+		panes:       map[string][]Pane{},
+		environment: map[string]string{},
+		layouts:     map[string]string{},
+	}
+	ran := func() string {
+		data, _ := os.ReadFile(hooks)
+		return strings.Join(strings.Fields(string(data)), " ")
+	}
+
+	if err := ApplyLayout(tmux, layout); err != nil {
+		t.Fatal(err)
+	}
+	if err := ApplyLayout(tmux, layout); err != nil {
+		t.Fatal(err)
+	}
+	if hooks := ran(); hooks != "pre post" {
+		t.Errorf("Expected the hooks to run once for the new session, got %q", hooks)
+	}
+
+	tmux.TmuxKillWindow(tmux.windows["my_project"][1].Id)
+	if err := ApplyLayout(tmux, layout); err != nil {
+		t.Fatal(err)
+	}
+	if hooks := ran(); hooks != "pre post pre post" {
+		t.Errorf("Expected the hooks to run again for the missing window, got %q", hooks)
+	}
+}
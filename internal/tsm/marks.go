@@ -0,0 +1,206 @@
+package tsm
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// bulkDoneMsg reports the outcome of an action that ran on several sessions
+// in the background.
+type bulkDoneMsg struct {
+	err error
+}
+
+// isMarked reports whether the i-th choice is marked or inside the range
+// being selected. Only sessions can be marked.
+func (m model) isMarked(i int) bool {
+	if m.choices[i].kind == TAG_CHOICE {
+		members := m.tagMembers(m.choices[i].tag)
+		return len(members) > 0 && !slices.ContainsFunc(members, func(name string) bool { return !m.marked[name] })
+	}
+	if m.choices[i].kind != SESSION_CHOICE {
+		return false
+	}
+	if m.ranging && i >= min(m.range_start, m.cursor) && i <= max(m.range_start, m.cursor) {
+		return true
+	}
+	return m.marked[m.choices[i].name()]
+}
+
+func (m *model) toggleMark() {
+	if m.cursor < len(m.choices) && m.choices[m.cursor].kind == TAG_CHOICE {
+		// A header marks its whole group, or clears it when all are marked.
+		marked := m.isMarked(m.cursor)
+		for _, name := range m.tagMembers(m.choices[m.cursor].tag) {
+			if marked {
+				delete(m.marked, name)
+			} else {
+				m.marked[name] = true
+			}
+		}
+		return
+	}
+	if m.cursor >= len(m.choices) || m.choices[m.cursor].kind != SESSION_CHOICE {
+		return
+	}
+	name := m.choices[m.cursor].name()
+	if m.marked[name] {
+		delete(m.marked, name)
+	} else {
+		m.marked[name] = true
+	}
+}
+
+// invertMarks flips the marks of the sessions that are shown, the ones
+// hidden by the filter keep theirs.
+func (m *model) invertMarks() {
+	// A session shown under several tags is flipped once.
+	seen := map[string]bool{}
+	for _, c := range m.choices {
+		if c.kind != SESSION_CHOICE || seen[c.name()] {
+			continue
+		}
+		seen[c.name()] = true
+		if m.marked[c.name()] {
+			delete(m.marked, c.name())
+		} else {
+			m.marked[c.name()] = true
+		}
+	}
+}
+
+// toggleRange starts selecting a range at the cursor, or marks everything
+// between where it started and the cursor when one is being selected.
+func (m *model) toggleRange() {
+	if !m.ranging {
+		m.ranging = true
+		m.range_start = m.cursor
+		return
+	}
+	for i := range m.choices {
+		if m.isMarked(i) {
+			m.marked[m.choices[i].name()] = true
+		}
+	}
+	m.ranging = false
+}
+
+// targets are the sessions a bulk action applies to: the marked ones, or
+// the one or the group under the cursor when nothing is marked.
+func (m model) targets() []string {
+	var names []string
+	for _, session := range m.sessions {
+		if m.marked[session.Name] {
+			names = append(names, session.Name)
+		}
+	}
+	if len(names) > 0 || m.cursor >= len(m.choices) {
+		return names
+	}
+	switch selected := m.choices[m.cursor]; selected.kind {
+	case SESSION_CHOICE:
+		names = append(names, selected.name())
+	case TAG_CHOICE:
+		names = m.tagMembers(selected.tag)
+	}
+	return names
+}
+
+// pruneMarks forgets marks of sessions that are gone.
+func (m *model) pruneMarks() {
+	for name := range m.marked {
+		if !slices.ContainsFunc(m.sessions, func(s Session) bool { return s.Name == name }) {
+			delete(m.marked, name)
+		}
+	}
+}
+
+// killSessions kills the targets and drops them from the list. Everything
+// killed goes to the trash first. It keeps going past sessions that cannot
+// be killed and returns the first error.
+func (m *model) killSessions(names []string) error {
+	saved, err := TakeSnapshot(m.tmux, false, names...)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	var killed []SnapshotSession
+	for _, session := range saved.Sessions {
+		if err := m.tmux.TmuxKillSession(session.Name); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		killed = append(killed, session)
+		m.sessions = slices.DeleteFunc(m.sessions, func(s Session) bool { return s.Name == session.Name })
+	}
+	m.trash.Prune(m.config.TrashRetention, time.Now())
+	m.trash.Add(killed, time.Now())
+	if err := SaveTrash(m.config.TrashPath, m.trash); err != nil {
+		errs = append(errs, err)
+	}
+	m.pruneMarks()
+	m.applyFilter(m.query())
+	if m.cursor >= len(m.choices) && m.cursor > 0 {
+		m.cursor = max(len(m.choices)-1, 0)
+	}
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+func (m *model) detachSessions(names []string) error {
+	for _, name := range names {
+		if err := m.tmux.TmuxDetachClients(name); err != nil {
+			return err
+		}
+	}
+	m.reloadSessions()
+	return m.err
+}
+
+// applyLayoutTo adds the windows of the layout file at path to every named
+// session that does not have them yet.
+func applyLayoutTo(tmux Tmuxer, path string, names []string) tea.Cmd {
+	return func() tea.Msg {
+		layout, err := LoadLayout(path)
+		if err != nil {
+			return bulkDoneMsg{err: err}
+		}
+		for _, name := range names {
+			layout.Name = name
+			if err := ApplyLayout(tmux, layout); err != nil {
+				return bulkDoneMsg{err: fmt.Errorf("%s: %w", name, err)}
+			}
+		}
+		return bulkDoneMsg{}
+	}
+}
+
+// exportSnapshot saves the named sessions into the snapshot at path,
+// keeping whatever else was saved there before.
+func exportSnapshot(tmux Tmuxer, path string, names []string) tea.Cmd {
+	return func() tea.Msg {
+		if len(path) == 0 {
+			return bulkDoneMsg{err: errors.New("no snapshot file to export to")}
+		}
+		snapshot, err := LoadSnapshot(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return bulkDoneMsg{err: err}
+		}
+		taken, err := TakeSnapshot(tmux, false, names...)
+		if err != nil {
+			return bulkDoneMsg{err: err}
+		}
+		snapshot.Merge(taken)
+		if err := SaveSnapshot(path, snapshot); err != nil {
+			return bulkDoneMsg{err: err}
+		}
+		return snapshotMsg{sessions: snapshot.Sessions}
+	}
+}
@@ -0,0 +1,133 @@
+package tsm
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func newTestMarkModel() model {
+	tmux := &MockTmux{sessions: testSessions("test_session_1", "test_session_2", "test_session_3", "test_session_4")}
+	return InitialSessionModel(tmux, Config{})
+}
+
+func markedNames(m model) []string {
+	var names []string
+	for i := range m.choices {
+		if m.isMarked(i) {
+			names = append(names, m.choices[i].name())
+		}
+	}
+	return names
+}
+
+func TestMarkSessions(t *testing.T) {
+	tests := []struct {
+		keys     []string
+		expected []string
+	}{
+		{[]string{" "}, []string{"test_session_1"}},
+		{[]string{" ", " "}, []string{"test_session_1", "test_session_2"}},
+		{[]string{" ", "k", " "}, nil},
+		{[]string{" ", "*"}, []string{"test_session_2", "test_session_3", "test_session_4"}},
+		{[]string{"j", "V", "j", "j"}, []string{"test_session_2", "test_session_3", "test_session_4"}},
+		{[]string{"j", "V", "j", "V", "j"}, []string{"test_session_2", "test_session_3"}},
+		{[]string{"j", "V", "j", "esc"}, nil},
+		{[]string{" ", " ", "esc"}, nil},
+	}
+
+	for _, test := range tests {
+		updModel, _ := sendKeys(newTestMarkModel(), test.keys...)
+		if names := markedNames(updModel.(model)); !slices.Equal(names, test.expected) {
+			t.Errorf("Expected %v to mark %v, got %v", test.keys, test.expected, names)
+		}
+	}
+}
+
+func TestBulkKillMarkedSessions(t *testing.T) {
+	updModel, _ := sendKeys(newTestMarkModel(), " ", "j", " ", "d", "y")
+	test_model := updModel.(model)
+	if names := sessionNames(test_model.sessions); !slices.Equal(names, []string{"test_session_2", "test_session_4"}) {
+		t.Errorf("Expected marked sessions to be killed, got %v", names)
+	}
+	if len(test_model.marked) != 0 {
+		t.Errorf("Expected marks of killed sessions to be gone, got %v", test_model.marked)
+	}
+	if test_model.err != nil {
+		t.Errorf("Expected no error, got %v", test_model.err)
+	}
+}
+
+func TestBulkDetachMarkedSessions(t *testing.T) {
+	test_model := newTestMarkModel()
+	tmux := test_model.tmux.(*MockTmux)
+	for i := range tmux.sessions {
+		tmux.sessions[i].Attached = 1
+	}
+
+	sendKeys(test_model, "*", "x")
+	for _, session := range tmux.sessions {
+		if session.Attached != 0 {
+			t.Errorf("Expected clients of %s to be detached", session.Name)
+		}
+	}
+}
+
+func TestBulkExportToSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	SaveSnapshot(path, Snapshot{Version: SnapshotVersion, Sessions: []SnapshotSession{{Name: "old"}, {Name: "test_session_2"}}})
+	tmux := &MockTmux{sessions: testSessions("test_session_1", "test_session_2", "test_session_3")}
+	test_model := InitialSessionModel(tmux, Config{SnapshotPath: path})
+
+	_, cmd := sendKeys(test_model, "j", " ", " ", "e")
+	msg, ok := cmd().(snapshotMsg)
+	if !ok {
+		t.Fatalf("Expected the export to deliver the new snapshot, got %v", msg)
+	}
+	snapshot, err := LoadSnapshot(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, len(snapshot.Sessions))
+	for i, session := range snapshot.Sessions {
+		names[i] = session.Name
+	}
+	if !slices.Equal(names, []string{"old", "test_session_2", "test_session_3"}) {
+		t.Errorf("Expected marked sessions to be merged into the snapshot, got %v", names)
+	}
+}
+
+func TestBulkApplyLayout(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, LayoutFileName), []byte("windows:\n  - name: logs\n"), 0o644)
+	tmux := &MockTmux{
+		sessions: testSessions("test_session_1", "test_session_2"),
+		windows: map[string][]Window{
+			"test_session_1": testWindows("editor"),
+			"test_session_2": testWindows("editor"),
+		},
+		panes:       map[string][]Pane{},
+		environment: map[string]string{},
+		layouts:     map[string]string{},
+	}
+	var test_model tea.Model = InitialSessionModel(tmux, Config{})
+
+	test_model, _ = sendKeys(test_model, "*", "a")
+	if test_model.(model).state != LAYOUT_STATE {
+		t.Fatalf("Expected state to be %d, got %d", LAYOUT_STATE, test_model.(model).state)
+	}
+	test_model.(model).inputs[LAYOUT_PATH_INPUT].SetValue(filepath.Join(dir, LayoutFileName))
+	test_model, cmd := sendKeys(test_model, "enter")
+	test_model, _ = test_model.Update(cmd())
+	if test_model.(model).err != nil {
+		t.Fatalf("Expected no error, got %v", test_model.(model).err)
+	}
+	for _, session := range []string{"test_session_1", "test_session_2"} {
+		if names := windowNames(tmux.windows[session]); !slices.Equal(names, []string{"editor", "logs"}) {
+			t.Errorf("Expected layout windows to be added to %s, got %v", session, names)
+		}
+	}
+}
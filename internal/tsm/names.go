@@ -0,0 +1,32 @@
+package tsm
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrEmptyName   = errors.New("name cannot be empty")
+	ErrIllegalName = errors.New(`name cannot contain "." or ":"`)
+	ErrNameTooLong = errors.New("name is too long")
+)
+
+// ValidateSessionName checks a name before it is handed to tmux, which
+// refuses empty and duplicate names and mangles "." and ":". Sessions named
+// like except are not counted as duplicates, so a session can be renamed to
+// itself. A maxLength of 0 means no limit.
+func ValidateSessionName(name string, sessions []Session, except string, maxLength int) error {
+	switch {
+	case len(strings.TrimSpace(name)) == 0:
+		return ErrEmptyName
+	case strings.ContainsAny(name, ".:"):
+		return ErrIllegalName
+	case maxLength > 0 && len([]rune(name)) > maxLength:
+		return fmt.Errorf("%w, at most %d characters", ErrNameTooLong, maxLength)
+	case name != except && slices.ContainsFunc(sessions, func(s Session) bool { return s.Name == name }):
+		return fmt.Errorf("%s: %w", name, ErrDuplicateSession)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package tsm
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateSessionName(t *testing.T) {
+	sessions := testSessions("main", "scratch")
+	tests := []struct {
+		name     string
+		except   string
+		expected error
+	}{
+		{"work", "", nil},
+		{"", "", ErrEmptyName},
+		{"  ", "", ErrEmptyName},
+		{"my.project", "", ErrIllegalName},
+		{"host:1", "", ErrIllegalName},
+		{"a-very-long-session-name", "", ErrNameTooLong},
+		{"main", "", ErrDuplicateSession},
+		{"main", "main", nil},
+	}
+
+	for _, test := range tests {
+		if err := ValidateSessionName(test.name, sessions, test.except, 16); !errors.Is(err, test.expected) {
+			t.Errorf("Expected %q to give %v, got %v", test.name, test.expected, err)
+		}
+	}
+}
+
+func TestSessionNameIsValidatedAsYouType(t *testing.T) {
+	test_model := InitialSessionModel(&MockTmux{sessions: testSessions("main")}, Config{MaxSessionNameLength: 32})
+
+	updModel, _ := sendKeys(test_model, "c", "m", "a", "i", "n")
+	if view := updModel.View(); !strings.Contains(view, ErrDuplicateSession.Error()) {
+		t.Errorf("Expected the duplicate name to be pointed out, got %s", view)
+	}
+	updModel, _ = sendKeys(updModel, "enter")
+	if updModel.(model).state != CREATE_STATE {
+		t.Errorf("Expected an invalid name not to be submitted")
+	}
+
+	updModel, _ = sendKeys(updModel, "2", "enter")
+	if names := sessionNames(updModel.(model).sessions); len(names) != 2 || names[1] != "main2" {
+		t.Errorf("Expected main2 to be created, got %v", names)
+	}
+}
+
+func TestSessionNameIsSanitized(t *testing.T) {
+	test_model := InitialSessionModel(&MockTmux{}, Config{SanitizeSessionNames: true})
+
+	updModel, _ := sendKeys(test_model, "c", "a", ".", "b", ":", "c")
+	if value := updModel.(model).inputs[NEW_SESSION_INPUT].Value(); value != "a_b_c" {
+		t.Errorf("Expected the name to be sanitized to a_b_c, got %s", value)
+	}
+}
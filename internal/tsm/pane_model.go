@@ -0,0 +1,369 @@
+package tsm
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss/list"
+)
+
+const (
+	JOIN_PANE_INPUT Input = iota
+)
+
+type paneKeyMap struct {
+	CursorUp        key.Binding
+	CursorDown      key.Binding
+	Delete          key.Binding
+	Enter           key.Binding
+	SplitHorizontal key.Binding
+	SplitVertical   key.Binding
+	Break           key.Binding
+	Join            key.Binding
+	Zoom            key.Binding
+	SwapUp          key.Binding
+	SwapDown        key.Binding
+	Back            key.Binding
+	Quit            key.Binding
+	Help            key.Binding
+}
+
+func (km paneKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{km.CursorUp, km.CursorDown, km.Delete, km.Enter, km.SplitHorizontal, km.SplitVertical},
+		{km.Break, km.Join, km.Zoom, km.SwapUp, km.SwapDown},
+		{km.Back, km.Quit},
+	}
+}
+
+func (km paneKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{
+		km.Back,
+		km.Help,
+	}
+}
+
+var default_pane_keys = paneKeyMap{
+	CursorUp: key.NewBinding(
+		key.WithKeys("k", "ctrl+p"),
+		key.WithHelp("ctrl+p/k", "move up"),
+	),
+	CursorDown: key.NewBinding(
+		key.WithKeys("j", "ctrl+n"),
+		key.WithHelp("ctrl+n/j", "move down"),
+	),
+	Delete: key.NewBinding(
+		key.WithKeys("d"),
+		key.WithHelp("d", "kill pane"),
+	),
+	Enter: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "select pane"),
+	),
+	SplitHorizontal: key.NewBinding(
+		key.WithKeys("|"),
+		key.WithHelp("|", "split horizontally"),
+	),
+	SplitVertical: key.NewBinding(
+		key.WithKeys("-"),
+		key.WithHelp("-", "split vertically"),
+	),
+	Break: key.NewBinding(
+		key.WithKeys("b"),
+		key.WithHelp("b", "break into window"),
+	),
+	Join: key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "join pane here"),
+	),
+	Zoom: key.NewBinding(
+		key.WithKeys("z"),
+		key.WithHelp("z", "toggle zoom"),
+	),
+	SwapUp: key.NewBinding(
+		key.WithKeys("K"),
+		key.WithHelp("K", "swap up"),
+	),
+	SwapDown: key.NewBinding(
+		key.WithKeys("J"),
+		key.WithHelp("J", "swap down"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("h", "left", "esc"),
+		key.WithHelp("h/esc", "back to windows"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("q", "ctrl+c"),
+		key.WithHelp("ctrl+c/q", "quit"),
+	),
+	Help: key.NewBinding(
+		key.WithKeys("?"),
+		key.WithHelp("?", "show help"),
+	),
+}
+
+type paneModel struct {
+	session Session
+	window  Window
+	panes   []Pane
+	cursor  int
+	state   State
+	inputs  []textinput.Model
+	focused Input
+	help    help.Model
+	keyMap  paneKeyMap
+	tmux    Tmuxer
+	styles  Styles
+	err     error
+}
+
+func InitialPaneModel(tmux Tmuxer, session Session, window Window, styles Styles) paneModel {
+	inputs := make([]textinput.Model, 1)
+	inputs[JOIN_PANE_INPUT] = createSessionInputBubble("Source pane, e.g. work:2.1")
+	inputs[JOIN_PANE_INPUT].CharLimit = 0
+
+	panes, err := tmux.TmuxListPanes(window.Id)
+	help := newHelp(styles)
+
+	m := paneModel{
+		session: session,
+		window:  window,
+		panes:   panes,
+		state:   MANAGE_STATE,
+		inputs:  inputs,
+		help:    help,
+		keyMap:  default_pane_keys,
+		tmux:    tmux,
+		styles:  styles,
+		err:     err,
+	}
+	for i, pane := range panes {
+		if pane.Active {
+			m.cursor = i
+		}
+	}
+
+	return m
+}
+
+func (m paneModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m paneModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if _, ok := msg.(refreshMsg); ok {
+		m.reload()
+		return m, nil
+	}
+
+	switch m.state {
+	case MANAGE_STATE:
+		return m.updateManageState(msg)
+	case JOIN_STATE:
+		return m.updateInputState(msg)
+	}
+
+	return m, nil
+}
+
+// reload fetches the pane list again and keeps the cursor in bounds.
+func (m *paneModel) reload() {
+	m.panes, m.err = m.tmux.TmuxListPanes(m.window.Id)
+	m.cursor = min(m.cursor, max(len(m.panes)-1, 0))
+}
+
+func (m paneModel) updateManageState(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if closeHelp(&m.help, msg) {
+		return m, nil
+	}
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		m.err = nil
+		switch {
+		case key.Matches(msg, m.keyMap.CursorUp):
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case key.Matches(msg, m.keyMap.CursorDown):
+			if m.cursor < len(m.panes)-1 {
+				m.cursor++
+			}
+		case key.Matches(msg, m.keyMap.Delete):
+			if len(m.panes) == 0 {
+				break
+			}
+			m.err = m.tmux.TmuxKillPane(m.panes[m.cursor].Id)
+			if m.err == nil {
+				m.reload()
+				// Killing the last pane takes the window down with it.
+				if len(m.panes) == 0 {
+					return m, back
+				}
+			}
+		case key.Matches(msg, m.keyMap.Enter):
+			if len(m.panes) == 0 {
+				break
+			}
+			m.err = m.tmux.TmuxSelectWindow(m.window.Id)
+			if m.err == nil {
+				m.err = m.tmux.TmuxSelectPane(m.panes[m.cursor].Id)
+			}
+			if m.err == nil {
+				m.err = m.tmux.TmuxSwitchSession(m.session.Name)
+			}
+			if m.err == nil {
+				return m, tea.Quit
+			}
+		case key.Matches(msg, m.keyMap.SplitHorizontal, m.keyMap.SplitVertical):
+			if len(m.panes) == 0 {
+				break
+			}
+			pane := m.panes[m.cursor]
+			_, m.err = m.tmux.TmuxSplitPane(pane.Id, key.Matches(msg, m.keyMap.SplitHorizontal), pane.Path)
+			if m.err == nil {
+				m.reload()
+			}
+		case key.Matches(msg, m.keyMap.Break):
+			if len(m.panes) == 0 {
+				break
+			}
+			m.err = m.tmux.TmuxBreakPane(m.panes[m.cursor].Id)
+			if m.err == nil {
+				m.reload()
+				if len(m.panes) == 0 {
+					return m, back
+				}
+			}
+		case key.Matches(msg, m.keyMap.Join):
+			if len(m.panes) == 0 {
+				break
+			}
+			m.state = JOIN_STATE
+			m.focused = JOIN_PANE_INPUT
+		case key.Matches(msg, m.keyMap.Zoom):
+			if len(m.panes) == 0 {
+				break
+			}
+			m.err = m.tmux.TmuxZoomPane(m.panes[m.cursor].Id)
+		case key.Matches(msg, m.keyMap.SwapUp):
+			if m.cursor == 0 {
+				break
+			}
+			m.err = m.tmux.TmuxSwapPane(m.panes[m.cursor].Id, m.panes[m.cursor-1].Id)
+			if m.err == nil {
+				m.cursor--
+				m.reload()
+			}
+		case key.Matches(msg, m.keyMap.SwapDown):
+			if m.cursor >= len(m.panes)-1 {
+				break
+			}
+			m.err = m.tmux.TmuxSwapPane(m.panes[m.cursor].Id, m.panes[m.cursor+1].Id)
+			if m.err == nil {
+				m.cursor++
+				m.reload()
+			}
+		case key.Matches(msg, m.keyMap.Back):
+			return m, back
+		case key.Matches(msg, m.keyMap.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, m.keyMap.Help):
+			m.help.ShowAll = true
+		}
+	}
+
+	return m, nil
+}
+
+func (m paneModel) updateInputState(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	m.inputs[m.focused].Focus()
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, default_form_keys.Cancel):
+			m.state = MANAGE_STATE
+			m.inputs[m.focused].Reset()
+			return m, nil
+		case key.Matches(msg, default_form_keys.Submit):
+			m.err = m.tmux.TmuxJoinPane(m.inputs[m.focused].Value(), m.panes[m.cursor].Id)
+			if m.err == nil {
+				m.state = MANAGE_STATE
+				m.inputs[m.focused].Reset()
+				m.reload()
+				return m, nil
+			}
+		}
+	}
+
+	m.inputs[m.focused], cmd = m.inputs[m.focused].Update(msg)
+	return m, cmd
+}
+
+func (m paneModel) viewManageState() string {
+	panes := list.New()
+	for i, pane := range m.panes {
+		cursor := " "
+		if i == m.cursor {
+			cursor = ">"
+			panes.Item(m.styles.selected.Render(fmt.Sprintf("%s %s", cursor, renderPane(pane))))
+		} else {
+			panes.Item(fmt.Sprintf("%s %s", cursor, renderPane(pane)))
+		}
+	}
+	panes = panes.Enumerator(blankEnumerator)
+
+	cwd := ""
+	if len(m.panes) > 0 {
+		cwd = "\n" + m.styles.help.Render("cwd: "+m.panes[m.cursor].Path)
+	}
+
+	return fmt.Sprintf(
+		"%s\n%s",
+		m.styles.root.Render(
+			fmt.Sprintf(
+				"%s\n%s%s%s",
+				m.styles.header.Render(fmt.Sprintf("Panes of %s:%s:", m.session.Name, m.window.Name)),
+				m.styles.list.Render(panes.String()),
+				cwd,
+				m.styles.renderStatus(m.err),
+			),
+		),
+		m.help.View(m.keyMap),
+	)
+}
+
+func (m paneModel) viewInputState() string {
+	return m.styles.root.Render(
+		fmt.Sprintf(
+			"%s\n%s%s",
+			m.styles.header.Render("Join pane from:"),
+			m.inputs[m.focused].View(),
+			m.styles.renderStatus(m.err),
+		),
+	)
+}
+
+func (m paneModel) View() string {
+	if m.help.ShowAll {
+		return m.styles.viewKeyOverlay(m.keyMap)
+	}
+	switch m.state {
+	case JOIN_STATE:
+		return m.viewInputState()
+	default:
+		return m.viewManageState()
+	}
+}
+
+func renderPane(pane Pane) string {
+	active := ""
+	if pane.Active {
+		active = " *"
+	}
+	return fmt.Sprintf("%d: %s%s [%d] %dx%d", pane.Index, pane.Command, active, pane.Pid, pane.Width, pane.Height)
+}
@@ -0,0 +1,93 @@
+package tsm
+
+import (
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func newTestPaneModel() paneModel {
+	tmux := &MockTmux{
+		sessions: testSessions("test_session_1"),
+		windows:  map[string][]Window{"test_session_1": testWindows("editor", "shell")},
+		panes: map[string][]Pane{
+			"@editor": {
+				{Id: "%1", Index: 0, Command: "nvim", Pid: 100, Path: "/src", Width: 80, Height: 24, Active: true},
+				{Id: "%2", Index: 1, Command: "zsh", Pid: 101, Path: "/src", Width: 80, Height: 24},
+			},
+			"@shell": {
+				{Id: "%3", Index: 0, Command: "zsh", Pid: 102, Path: "/tmp", Width: 160, Height: 48, Active: true},
+			},
+		},
+	}
+	return InitialPaneModel(tmux, tmux.sessions[0], tmux.windows["test_session_1"][0], configStyles(Config{}))
+}
+
+func paneIds(panes []Pane) []string {
+	ids := make([]string, len(panes))
+	for i, pane := range panes {
+		ids[i] = pane.Id
+	}
+	return ids
+}
+
+func TestPaneOperations(t *testing.T) {
+	tests := []struct {
+		keys            []string
+		expected_cursor int
+		expected_panes  []string
+	}{
+		{[]string{"j"}, 1, []string{"%1", "%2"}},
+		{[]string{"J"}, 1, []string{"%2", "%1"}},
+		{[]string{"j", "K"}, 0, []string{"%2", "%1"}},
+		{[]string{"|"}, 0, []string{"%1", "%2", "%100"}},
+		{[]string{"-"}, 0, []string{"%1", "%2", "%100"}},
+		{[]string{"d"}, 0, []string{"%2"}},
+		{[]string{"j", "b"}, 0, []string{"%1"}},
+		{[]string{"i", "%", "3", "enter"}, 0, []string{"%1", "%2", "%3"}},
+	}
+
+	for _, test := range tests {
+		updModel, _ := sendKeys(newTestPaneModel(), test.keys...)
+		test_model := updModel.(paneModel)
+		if test_model.state != MANAGE_STATE {
+			t.Errorf("Expected state to be %d, got %d", MANAGE_STATE, test_model.state)
+		}
+		if test_model.cursor != test.expected_cursor {
+			t.Errorf("Expected cursor to be %d, got %d", test.expected_cursor, test_model.cursor)
+		}
+		if !slices.Equal(paneIds(test_model.panes), test.expected_panes) {
+			t.Errorf("Expected panes %v, got %v", test.expected_panes, paneIds(test_model.panes))
+		}
+	}
+}
+
+func TestPaneZoomAndSelect(t *testing.T) {
+	updModel, _ := sendKeys(newTestPaneModel(), "j", "z")
+	mockTmux := updModel.(paneModel).tmux.(*MockTmux)
+	if mockTmux.zoomed_pane != "%2" {
+		t.Errorf("Expected pane %%2 to be zoomed, got %s", mockTmux.zoomed_pane)
+	}
+
+	_, cmd := sendKeys(updModel, "enter")
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Fatalf("Expected cmd to be tea.Quit, got %v", cmd())
+	}
+	if !mockTmux.panes["@editor"][1].Active {
+		t.Errorf("Expected pane %%2 to be active")
+	}
+	if mockTmux.active_session != "test_session_1" {
+		t.Errorf("Expected active session to be test_session_1, got %s", mockTmux.active_session)
+	}
+}
+
+func TestKillingLastPaneGoesBack(t *testing.T) {
+	updModel, cmd := sendKeys(newTestPaneModel(), "d", "d")
+	if len(updModel.(paneModel).panes) != 0 {
+		t.Fatalf("Expected no panes left, got %v", paneIds(updModel.(paneModel).panes))
+	}
+	if _, ok := cmd().(backMsg); !ok {
+		t.Errorf("Expected cmd to be back, got %v", cmd())
+	}
+}
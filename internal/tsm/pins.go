@@ -0,0 +1,203 @@
+package tsm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// PinSlots is how many sessions can be pinned, one for each of 1 to 9.
+const PinSlots = 9
+
+// ErrNoPin is returned when jumping to a slot nothing is pinned to.
+var ErrNoPin = errors.New("nothing pinned")
+
+// PinsVersion is bumped whenever the pins format changes in a way older
+// versions of tsm cannot read.
+const PinsVersion = 1
+
+// Pins are the sessions pinned to slots 1 to PinSlots. They are kept by
+// name so a pin outlives its session, and with the directory to start it
+// again in.
+type Pins struct {
+	Version int   `json:"version"`
+	Pins    []Pin `json:"pins"`
+}
+
+type Pin struct {
+	Slot    int    `json:"slot"`
+	Session string `json:"session"`
+	Dir     string `json:"dir"`
+}
+
+// DefaultPinsPath is where the pins live unless told otherwise.
+func DefaultPinsPath() string {
+	return filepath.Join(stateDir(), "pins.json")
+}
+
+// LoadPins reads the pins at path. A missing file or an empty path is no
+// pins.
+func LoadPins(path string) (Pins, error) {
+	pins := Pins{Version: PinsVersion}
+	if len(path) == 0 {
+		return pins, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return pins, nil
+	}
+	if err != nil {
+		return pins, err
+	}
+	if err := json.Unmarshal(data, &pins); err != nil {
+		return pins, fmt.Errorf("%s: %w", path, err)
+	}
+	if pins.Version > PinsVersion {
+		return Pins{Version: PinsVersion}, fmt.Errorf("%s: pins version %d is newer than this tsm understands", path, pins.Version)
+	}
+	return pins, nil
+}
+
+// SavePins writes the pins to path, or only keeps them in memory when path
+// is empty.
+func SavePins(path string, pins Pins) error {
+	if len(path) == 0 {
+		return nil
+	}
+	pins.Version = PinsVersion
+	return writeJSON(path, pins)
+}
+
+// Slot returns the pin in slot.
+func (p Pins) Slot(slot int) (Pin, bool) {
+	i := slices.IndexFunc(p.Pins, func(pin Pin) bool { return pin.Slot == slot })
+	if i < 0 {
+		return Pin{}, false
+	}
+	return p.Pins[i], true
+}
+
+// SlotOf is the slot session is pinned to, 0 when it is not pinned.
+func (p Pins) SlotOf(session string) int {
+	i := slices.IndexFunc(p.Pins, func(pin Pin) bool { return pin.Session == session })
+	if i < 0 {
+		return 0
+	}
+	return p.Pins[i].Slot
+}
+
+// FreeSlot is the lowest slot nothing is pinned to, 0 when all are taken.
+func (p Pins) FreeSlot() int {
+	for slot := 1; slot <= PinSlots; slot++ {
+		if _, taken := p.Slot(slot); !taken {
+			return slot
+		}
+	}
+	return 0
+}
+
+// Pin puts session in slot, replacing whatever was pinned there and moving
+// the session out of the slot it had before.
+func (p *Pins) Pin(slot int, session string, dir string) {
+	p.Pins = slices.DeleteFunc(p.Pins, func(pin Pin) bool { return pin.Slot == slot || pin.Session == session })
+	p.Pins = append(p.Pins, Pin{Slot: slot, Session: session, Dir: dir})
+	slices.SortFunc(p.Pins, func(a, b Pin) int { return a.Slot - b.Slot })
+}
+
+// Unpin frees the slot of session.
+func (p *Pins) Unpin(session string) {
+	p.Pins = slices.DeleteFunc(p.Pins, func(pin Pin) bool { return pin.Session == session })
+}
+
+// Rename keeps the pin of a session when it is renamed.
+func (p *Pins) Rename(oldSession string, session string) {
+	for i := range p.Pins {
+		if p.Pins[i].Session == oldSession {
+			p.Pins[i].Session = session
+		}
+	}
+}
+
+// JumpToPin switches to the session pinned to slot, starting it in its
+// directory first when it is not running.
+func JumpToPin(tmux Tmuxer, pins Pins, slot int) error {
+	pin, ok := pins.Slot(slot)
+	if !ok {
+		return fmt.Errorf("slot %d: %w", slot, ErrNoPin)
+	}
+	sessions, err := tmux.TmuxListSessions()
+	if err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(sessions, func(s Session) bool { return s.Name == pin.Session }) {
+		if err := tmux.TmuxCreateSession(CreateSessionOptions{Name: pin.Session, Dir: pin.Dir}); err != nil {
+			return err
+		}
+	}
+	return tmux.TmuxSwitchSession(pin.Session)
+}
+
+// jumpToPin switches to the session in slot and quits, or stays with an
+// error when that fails.
+func (m model) jumpToPin(slot int) (tea.Model, tea.Cmd) {
+	m.err = JumpToPin(m.tmux, m.pins, slot)
+	if m.err == nil {
+		return m, tea.Quit
+	}
+	// The session may have been started even though we could not get to it.
+	err := m.err
+	m.reloadSessions()
+	m.err = err
+	return m, m.requestPreview()
+}
+
+// togglePin pins the selected session to the first free slot, or unpins it
+// when it is pinned already.
+func (m *model) togglePin() {
+	if m.cursor >= len(m.choices) {
+		return
+	}
+	selected := m.choices[m.cursor]
+	if selected.slot > 0 {
+		m.pins.Unpin(selected.name())
+		m.savePins(selected.name())
+		return
+	}
+	slot := m.pins.FreeSlot()
+	if slot == 0 {
+		m.err = fmt.Errorf("all %d slots are taken", PinSlots)
+		return
+	}
+	m.pinTo(slot)
+}
+
+// pinTo pins the selected session to slot. Only running sessions can be
+// pinned, a pin needs a directory to start the session in again.
+func (m *model) pinTo(slot int) {
+	if m.cursor >= len(m.choices) || slot < 1 || slot > PinSlots {
+		return
+	}
+	selected := m.choices[m.cursor]
+	switch selected.kind {
+	case SESSION_CHOICE:
+		m.pins.Pin(slot, selected.session.Name, selected.session.Path)
+	case PIN_CHOICE:
+		m.pins.Pin(slot, selected.pin.Session, selected.pin.Dir)
+	default:
+		return
+	}
+	m.savePins(selected.name())
+}
+
+// savePins writes the pins and rebuilds the list with the cursor still on
+// the session that was pinned or unpinned.
+func (m *model) savePins(name string) {
+	m.err = SavePins(m.config.PinsPath, m.pins)
+	m.applyFilter(m.query())
+	m.cursor = max(slices.IndexFunc(m.choices, func(c choice) bool { return c.name() == name }), 0)
+}
@@ -0,0 +1,128 @@
+package tsm
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestPins(t *testing.T) {
+	var pins Pins
+	pins.Pin(2, "api", "/src/api")
+	pins.Pin(1, "notes", "/notes")
+	if slot := pins.FreeSlot(); slot != 3 {
+		t.Errorf("Expected slot 3 to be free, got %d", slot)
+	}
+
+	pins.Pin(2, "notes", "/notes")
+	if pins.SlotOf("notes") != 2 || pins.SlotOf("api") != 0 {
+		t.Errorf("Expected notes to move into the slot of api, got %v", pins.Pins)
+	}
+
+	pins.Rename("notes", "docs")
+	if pin, ok := pins.Slot(2); !ok || pin.Session != "docs" {
+		t.Errorf("Expected the pin to follow the rename, got %v", pins.Pins)
+	}
+
+	path := filepath.Join(t.TempDir(), "pins.json")
+	if err := SavePins(path, pins); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadPins(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(loaded.Pins, pins.Pins) {
+		t.Errorf("Expected %v back, got %v", pins.Pins, loaded.Pins)
+	}
+}
+
+func pinnedModel(t *testing.T) (model, *MockTmux) {
+	path := filepath.Join(t.TempDir(), "pins.json")
+	pins := Pins{Pins: []Pin{{Slot: 1, Session: "gone", Dir: "/src/gone"}, {Slot: 2, Session: "c"}}}
+	if err := SavePins(path, pins); err != nil {
+		t.Fatal(err)
+	}
+	tmux := &MockTmux{sessions: testSessions("a", "b", "c")}
+	return InitialSessionModel(tmux, Config{PinsPath: path}), tmux
+}
+
+func TestPinnedSessionsComeFirst(t *testing.T) {
+	m, _ := pinnedModel(t)
+
+	expected := []string{"gone", "c", "a", "b"}
+	if names := choiceNames(m.choices); !slices.Equal(names, expected) {
+		t.Fatalf("Expected %v, got %v", expected, names)
+	}
+	if m.choices[0].kind != PIN_CHOICE || m.choices[1].slot != 2 {
+		t.Errorf("Expected gone to be a pin without a session and c in slot 2")
+	}
+}
+
+func TestJumpToPin(t *testing.T) {
+	m, tmux := pinnedModel(t)
+
+	_, cmd := sendKeys(m, "2")
+	if tmux.active_session != "c" || cmd == nil || cmd() != tea.Quit() {
+		t.Errorf("Expected 2 to switch to c and quit, got %s", tmux.active_session)
+	}
+
+	_, cmd = sendKeys(m, "1")
+	if tmux.active_session != "gone" || cmd == nil || cmd() != tea.Quit() {
+		t.Errorf("Expected 1 to switch to gone and quit, got %s", tmux.active_session)
+	}
+	if len(tmux.created) != 1 || tmux.created[0].Dir != "/src/gone" {
+		t.Errorf("Expected gone to be started in its directory, got %v", tmux.created)
+	}
+
+	updated, _ := sendKeys(m, "5")
+	if err := updated.(model).err; !errors.Is(err, ErrNoPin) {
+		t.Errorf("Expected an empty slot to report ErrNoPin, got %v", err)
+	}
+}
+
+func TestPinKeys(t *testing.T) {
+	m, _ := pinnedModel(t)
+
+	// a is the third row, p pins it to the first free slot.
+	updated, _ := sendKeys(m, "j", "j", "p")
+	m = updated.(model)
+	if slot := m.pins.SlotOf("a"); slot != 3 {
+		t.Fatalf("Expected a in slot 3, got %d", slot)
+	}
+	if m.choices[m.cursor].name() != "a" {
+		t.Errorf("Expected the cursor to stay on a, got %s", m.choices[m.cursor].name())
+	}
+
+	updated, _ = sendKeys(m, "alt+1")
+	m = updated.(model)
+	pins, err := LoadPins(m.config.PinsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pins.SlotOf("a") != 1 || pins.SlotOf("gone") != 0 {
+		t.Errorf("Expected a to take slot 1 on disk, got %v", pins.Pins)
+	}
+
+	updated, _ = sendKeys(m, "p")
+	if slot := updated.(model).pins.SlotOf("a"); slot != 0 {
+		t.Errorf("Expected p to unpin a, got slot %d", slot)
+	}
+}
+
+func TestLoadPinsRefusesNewerVersions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pins.json")
+	if err := os.WriteFile(path, []byte(`{"version": 99, "pins": []}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPins(path); err == nil {
+		t.Fatalf("Expected an error for newer pins")
+	}
+	if m := InitialSessionModel(&MockTmux{}, Config{PinsPath: path}); m.err == nil || len(m.config.PinsPath) > 0 {
+		t.Errorf("Expected the newer pins to be reported and left alone, got %v", m.err)
+	}
+}
@@ -0,0 +1,85 @@
+package tsm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// popupMinRows keeps a popup with only a few sessions from looking
+	// cramped.
+	popupMinRows = 5
+	// popupBorder is what the border of a popup takes on either side.
+	popupBorder = 1
+)
+
+// Client is a terminal attached to tmux.
+type Client struct {
+	TTY    string
+	Width  int
+	Height int
+}
+
+// CurrentClient describes the client given to UseClient, or the one tmux
+// takes for current. Clients belong to the server tsm runs inside of, so
+// it is asked whatever server this Tmux talks to.
+func (tmux *Tmux) CurrentClient() (Client, error) {
+	args := append(tmux.onClient("display-message", "-c"), "-p", "#{client_tty}\t#{client_width}\t#{client_height}")
+	out, err := (&Tmux{}).run(args...)
+	if err != nil {
+		return Client{}, err
+	}
+	return parseClient(out)
+}
+
+func parseClient(out string) (Client, error) {
+	fields := strings.Split(strings.TrimSpace(out), "\t")
+	if len(fields) != 3 || len(fields[0]) == 0 {
+		return Client{}, fmt.Errorf("unexpected client %q", out)
+	}
+	width, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return Client{}, fmt.Errorf("bad client width %q: %w", fields[1], err)
+	}
+	height, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return Client{}, fmt.Errorf("bad client height %q: %w", fields[2], err)
+	}
+	return Client{TTY: fields[0], Width: width, Height: height}, nil
+}
+
+// PopupSize fits a popup listing rows sessions with a list listWidth wide
+// into client. The list and the preview go side by side when they fit, the
+// compact layout is used when they do not.
+func PopupSize(client Client, rows int, listWidth int) (width int, height int, compact bool) {
+	if listWidth <= 0 {
+		listWidth = DefaultListWidth
+	}
+	rows = max(rows, popupMinRows)
+	// Header with its padding, list with its border and the status line,
+	// next to the preview with its border, then the help.
+	width = listWidth + 10 + defaultPreviewWidth + 2 + 2*popupBorder
+	height = max(rows+6, defaultPreviewHeight+2) + 1 + 2*popupBorder
+	if width > client.Width {
+		compact = true
+		width = listWidth + 2 + 2*popupBorder
+		height = rows + 5 + 2*popupBorder
+	}
+	return min(width, client.Width), min(height, client.Height), compact
+}
+
+// Popup runs command in a popup width by height over client, closed once
+// command exits.
+func (tmux *Tmux) Popup(client Client, width int, height int, command []string) error {
+	quoted := make([]string, len(command))
+	for i, arg := range command {
+		quoted[i] = shellQuote(arg)
+	}
+	_, err := (&Tmux{}).run(
+		"display-popup", "-c", client.TTY, "-E",
+		"-w", strconv.Itoa(width), "-h", strconv.Itoa(height),
+		strings.Join(quoted, " "),
+	)
+	return err
+}
@@ -0,0 +1,65 @@
+package tsm
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestPopupSize(t *testing.T) {
+	cases := []struct {
+		name    string
+		client  Client
+		rows    int
+		width   int
+		height  int
+		compact bool
+	}{
+		{"wide client", Client{Width: 200, Height: 60}, 3, 114, 25, false},
+		{"many sessions", Client{Width: 200, Height: 60}, 30, 114, 39, false},
+		{"short client", Client{Width: 200, Height: 20}, 30, 114, 20, false},
+		{"narrow client", Client{Width: 80, Height: 40}, 8, 44, 15, true},
+		{"tiny client", Client{Width: 30, Height: 10}, 8, 30, 10, true},
+	}
+	for _, c := range cases {
+		width, height, compact := PopupSize(c.client, c.rows, DefaultListWidth)
+		if width != c.width || height != c.height || compact != c.compact {
+			t.Errorf("%s: expected %dx%d compact %v, got %dx%d compact %v", c.name, c.width, c.height, c.compact, width, height, compact)
+		}
+	}
+}
+
+func TestParseClient(t *testing.T) {
+	client, err := parseClient("/dev/pts/3\t120\t40\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client != (Client{TTY: "/dev/pts/3", Width: 120, Height: 40}) {
+		t.Errorf("Expected the client on /dev/pts/3, got %+v", client)
+	}
+	if _, err := parseClient("\t\t\n"); err == nil {
+		t.Errorf("Expected an error without a client")
+	}
+}
+
+func TestCompactLayout(t *testing.T) {
+	tmux := &MockTmux{sessions: []Session{{Name: "api", Windows: 1}, {Name: "web", Windows: 2}}}
+	test_model := InitialSessionModel(tmux, Config{Compact: true})
+	if test_model.requestPreview() != nil {
+		t.Errorf("Expected no preview to be captured in the compact layout")
+	}
+
+	updModel, _ := test_model.Update(tea.WindowSizeMsg{Width: 200, Height: 50})
+	lines := strings.Split(updModel.View(), "\n")
+	if !strings.Contains(lines[0], "Sessions:") {
+		t.Errorf("Expected the header on the first line without padding, got %q", lines[0])
+	}
+	// Everything above the help is the list, with no preview next to it.
+	for _, line := range lines[:len(lines)-1] {
+		if width := lipgloss.Width(line); width != DefaultListWidth+2 {
+			t.Errorf("Expected the view to be as wide as the list, got %d for %q", width, line)
+		}
+	}
+}
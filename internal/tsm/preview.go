@@ -0,0 +1,46 @@
+package tsm
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	defaultPreviewWidth  = 60
+	defaultPreviewHeight = 20
+	minPreviewWidth      = 20
+)
+
+// previewMsg carries the captured contents of a session's active pane.
+type previewMsg struct {
+	session string
+	content string
+	err     error
+}
+
+func capturePreview(tmux Tmuxer, session string) tea.Cmd {
+	return func() tea.Msg {
+		content, err := tmux.TmuxCapturePane(exactSession(session) + ":")
+		return previewMsg{session: session, content: content, err: err}
+	}
+}
+
+// clipPreview keeps the bottom-most lines of a capture that fit into the
+// panel and cuts every line to the panel width without breaking escape
+// sequences. Each line is terminated with a reset so colors do not bleed
+// into the border.
+func clipPreview(content string, width int, height int) string {
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	for len(lines) > 0 && len(strings.TrimSpace(ansi.Strip(lines[len(lines)-1]))) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) > height {
+		lines = lines[len(lines)-height:]
+	}
+	for i, line := range lines {
+		lines[i] = ansi.Truncate(line, width, "") + "\x1b[0m"
+	}
+	return strings.Join(lines, "\n")
+}
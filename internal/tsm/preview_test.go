@@ -0,0 +1,39 @@
+package tsm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestClipPreview(t *testing.T) {
+	content := strings.Join([]string{
+		"first line",
+		"\x1b[31mred text that is way too long\x1b[0m",
+		"third",
+		"",
+		"   ",
+		"",
+	}, "\n")
+
+	clipped := clipPreview(content, 8, 2)
+	lines := strings.Split(clipped, "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d: %q", len(lines), clipped)
+	}
+	if ansi.Strip(lines[0]) != "red text" {
+		t.Errorf("Expected truncated red line, got %q", ansi.Strip(lines[0]))
+	}
+	if !strings.HasPrefix(lines[0], "\x1b[31m") {
+		t.Errorf("Expected colors to be preserved, got %q", lines[0])
+	}
+	if ansi.Strip(lines[1]) != "third" {
+		t.Errorf("Expected trailing blank lines to be dropped, got %q", ansi.Strip(lines[1]))
+	}
+	for _, line := range lines {
+		if !strings.HasSuffix(line, "\x1b[0m") {
+			t.Errorf("Expected line to end with a reset, got %q", line)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package tsm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Project is a directory that looks like the root of something worth its own
+// session.
+type Project struct {
+	Name string
+	Path string
+}
+
+// projectsMsg delivers the result of a background project scan.
+type projectsMsg struct {
+	projects []Project
+}
+
+func discoverProjects(config Config) tea.Cmd {
+	if len(config.ProjectRoots) == 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		return projectsMsg{projects: DiscoverProjects(config.ProjectRoots, config.ProjectDepth, config.ProjectMarkers)}
+	}
+}
+
+// DiscoverProjects walks every root up to depth levels down and returns the
+// directories containing one of the markers. The walk does not descend into
+// a project once it is found, and hidden directories are skipped. Roots that
+// do not exist are ignored.
+func DiscoverProjects(roots []string, depth int, markers []string) []Project {
+	var projects []Project
+	for _, root := range roots {
+		projects = append(projects, walkProjects(expandHome(root), depth, markers)...)
+	}
+
+	// Projects with the same folder name would fight over the session name,
+	// so those get their parent folder in front.
+	counts := make(map[string]int)
+	for _, project := range projects {
+		counts[project.Name]++
+	}
+	for i, project := range projects {
+		if counts[project.Name] > 1 {
+			projects[i].Name = SessionNameFor(filepath.Base(filepath.Dir(project.Path))) + "/" + project.Name
+		}
+	}
+
+	return projects
+}
+
+func walkProjects(dir string, depth int, markers []string) []Project {
+	if isProject(dir, markers) {
+		return []Project{{Name: SessionNameFor(filepath.Base(dir)), Path: dir}}
+	}
+	if depth <= 0 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var projects []Project
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		projects = append(projects, walkProjects(filepath.Join(dir, entry.Name()), depth-1, markers)...)
+	}
+	return projects
+}
+
+func isProject(dir string, markers []string) bool {
+	for _, marker := range markers {
+		if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// SessionNameFor turns a folder name into the session name tmux would end up
+// with, as tmux itself replaces "." and ":" in session names.
+func SessionNameFor(folder string) string {
+	return strings.NewReplacer(".", "_", ":", "_").Replace(folder)
+}
+
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
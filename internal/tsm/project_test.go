@@ -0,0 +1,68 @@
+package tsm
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestDiscoverProjects(t *testing.T) {
+	root := t.TempDir()
+	for _, path := range []string{
+		"api/.git",
+		"api/internal/nested/.git",
+		"web.site/package.json",
+		"group/tools/go.mod",
+		"group/deep/er/go.mod",
+		".hidden/repo/.git",
+		"notes/todo.txt",
+		"other/api/Cargo.toml",
+	} {
+		if err := os.MkdirAll(filepath.Join(root, filepath.Dir(path)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, path), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	projects := DiscoverProjects([]string{root, filepath.Join(root, "missing")}, 2, DefaultConfig().ProjectMarkers)
+
+	var names []string
+	for _, project := range projects {
+		names = append(names, project.Name)
+	}
+	expected := []string{SessionNameFor(filepath.Base(root)) + "/api", "tools", "other/api", "web_site"}
+	if !slices.Equal(names, expected) {
+		t.Errorf("Expected projects %v, got %v", expected, names)
+	}
+	if projects[1].Path != filepath.Join(root, "group", "tools") {
+		t.Errorf("Expected tools to live in %s, got %s", filepath.Join(root, "group", "tools"), projects[1].Path)
+	}
+}
+
+func TestSelectingProjectCreatesSession(t *testing.T) {
+	tmux := &MockTmux{sessions: testSessions("main")}
+	test_model := InitialSessionModel(tmux, DefaultConfig())
+	updModel, _ := test_model.Update(projectsMsg{projects: []Project{
+		{Name: "main", Path: "/src/main"},
+		{Name: "api", Path: "/src/api"},
+	}})
+	if names := choiceNames(updModel.(model).choices); !slices.Equal(names, []string{"main", "api"}) {
+		t.Fatalf("Expected opened projects to be merged away, got %v", names)
+	}
+
+	_, cmd := sendKeys(updModel, "j", "enter")
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Fatalf("Expected cmd to be tea.Quit, got %v", cmd())
+	}
+	if tmux.active_session != "api" {
+		t.Errorf("Expected to switch to api, got %s", tmux.active_session)
+	}
+	if created := tmux.sessions[len(tmux.sessions)-1]; created.Name != "api" || created.Path != "/src/api" {
+		t.Errorf("Expected api to be created in /src/api, got %+v", created)
+	}
+}
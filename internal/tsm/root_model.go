@@ -13,22 +13,139 @@ const (
 	PANE_MANAGEMENT
 )
 
+// openWindowsMsg asks the root model to drill into the windows of a session.
+type openWindowsMsg struct {
+	session Session
+}
+
+// openPanesMsg asks the root model to drill into the panes of a window.
+type openPanesMsg struct {
+	session Session
+	window  Window
+}
+
+// backMsg asks the root model to go one level up the hierarchy, from
+// sessions that is the list of servers.
+type backMsg struct{}
+
+// refreshMsg tells a model that tmux state may have changed behind its back.
+type refreshMsg struct{}
+
+func openWindows(session Session) tea.Cmd {
+	return func() tea.Msg {
+		return openWindowsMsg{session: session}
+	}
+}
+
+func openPanes(session Session, window Window) tea.Cmd {
+	return func() tea.Msg {
+		return openPanesMsg{session: session, window: window}
+	}
+}
+
+func back() tea.Msg {
+	return backMsg{}
+}
+
 type rootModel struct {
-	activeModel tea.Model
+	state    appState
+	servers  tea.Model
+	sessions tea.Model
+	windows  tea.Model
+	panes    tea.Model
+	tmux     Tmuxer
+	origin   Tmuxer
+	styles   Styles
+	config   Config
+	size     tea.WindowSizeMsg
 }
 
-func InitialRootModel(tmux Tmuxer) rootModel {
-	return rootModel{activeModel: InitialSessionModel(tmux)}
+func InitialRootModel(tmux Tmuxer, config Config) rootModel {
+	m := rootModel{
+		state:    SESSION_MANAGEMENT,
+		sessions: InitialSessionModel(tmux, config),
+		tmux:     tmux,
+		origin:   tmux,
+		styles:   configStyles(config),
+		config:   config,
+	}
+	if config.DefaultMode == SERVERS_MODE {
+		m.servers = InitialServerModel(tmux, m.styles)
+		m.state = CHOOSING
+	}
+	return m
 }
 
 func (m rootModel) Init() tea.Cmd {
-	return nil
+	if m.state == CHOOSING {
+		return m.servers.Init()
+	}
+	return m.sessions.Init()
 }
 
 func (m rootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	return m.activeModel.Update(msg)
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case openServerMsg:
+		// Everything below the server level talks to the chosen server.
+		m.tmux = m.tmux.TmuxOnServer(msg.server)
+		m.sessions, _ = InitialSessionModel(m.tmux, m.config).Update(m.size)
+		m.state = SESSION_MANAGEMENT
+		return m, m.sessions.Init()
+	case openWindowsMsg:
+		m.windows = InitialWindowModel(m.tmux, msg.session, m.styles)
+		m.state = WINDOW_MANAGEMENT
+		return m, m.windows.Init()
+	case openPanesMsg:
+		m.panes = InitialPaneModel(m.tmux, msg.session, msg.window, m.styles)
+		m.state = PANE_MANAGEMENT
+		return m, m.panes.Init()
+	case tea.WindowSizeMsg:
+		m.size = msg
+		// The session model keeps the size even while it is not on screen.
+		if m.state != SESSION_MANAGEMENT {
+			m.sessions, _ = m.sessions.Update(msg)
+		}
+	case backMsg:
+		switch m.state {
+		case SESSION_MANAGEMENT:
+			// The servers are listed from the one tsm was started on, not
+			// the one being browsed.
+			m.servers = InitialServerModel(m.origin, m.styles)
+			m.state = CHOOSING
+		case WINDOW_MANAGEMENT:
+			m.state = SESSION_MANAGEMENT
+			m.sessions, cmd = m.sessions.Update(refreshMsg{})
+		case PANE_MANAGEMENT:
+			m.state = WINDOW_MANAGEMENT
+			m.windows, cmd = m.windows.Update(refreshMsg{})
+		}
+		return m, cmd
+	}
+
+	switch m.state {
+	case CHOOSING:
+		m.servers, cmd = m.servers.Update(msg)
+	case WINDOW_MANAGEMENT:
+		m.windows, cmd = m.windows.Update(msg)
+	case PANE_MANAGEMENT:
+		m.panes, cmd = m.panes.Update(msg)
+	default:
+		m.sessions, cmd = m.sessions.Update(msg)
+	}
+
+	return m, cmd
 }
 
 func (m rootModel) View() string {
-	return m.activeModel.View()
+	switch m.state {
+	case CHOOSING:
+		return m.servers.View()
+	case WINDOW_MANAGEMENT:
+		return m.windows.View()
+	case PANE_MANAGEMENT:
+		return m.panes.View()
+	default:
+		return m.sessions.View()
+	}
 }
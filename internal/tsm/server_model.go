@@ -0,0 +1,186 @@
+package tsm
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss/list"
+)
+
+type serverKeyMap struct {
+	CursorUp   key.Binding
+	CursorDown key.Binding
+	Enter      key.Binding
+	Refresh    key.Binding
+	Quit       key.Binding
+	Help       key.Binding
+}
+
+func (km serverKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{km.CursorUp, km.CursorDown, km.Enter, km.Refresh, km.Quit},
+	}
+}
+
+func (km serverKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{
+		km.Help,
+	}
+}
+
+var default_server_keys = serverKeyMap{
+	CursorUp: key.NewBinding(
+		key.WithKeys("k", "ctrl+p"),
+		key.WithHelp("ctrl+p/k", "move up"),
+	),
+	CursorDown: key.NewBinding(
+		key.WithKeys("j", "ctrl+n"),
+		key.WithHelp("ctrl+n/j", "move down"),
+	),
+	Enter: key.NewBinding(
+		key.WithKeys("enter", "l", "right"),
+		key.WithHelp("enter/l", "browse sessions"),
+	),
+	Refresh: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "refresh"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("q", "ctrl+c"),
+		key.WithHelp("ctrl+c/q", "quit"),
+	),
+	Help: key.NewBinding(
+		key.WithKeys("?"),
+		key.WithHelp("?", "show help"),
+	),
+}
+
+// openServerMsg asks the root model to browse the sessions of a server.
+type openServerMsg struct {
+	server Server
+}
+
+func openServer(server Server) tea.Cmd {
+	return func() tea.Msg {
+		return openServerMsg{server: server}
+	}
+}
+
+type serverModel struct {
+	servers []Server
+	cursor  int
+	help    help.Model
+	keyMap  serverKeyMap
+	tmux    Tmuxer
+	styles  Styles
+	err     error
+}
+
+func InitialServerModel(tmux Tmuxer, styles Styles) serverModel {
+	servers, err := tmux.TmuxListServers()
+	help := newHelp(styles)
+
+	m := serverModel{
+		servers: servers,
+		help:    help,
+		keyMap:  default_server_keys,
+		tmux:    tmux,
+		styles:  styles,
+		err:     err,
+	}
+	for i, server := range servers {
+		if server.Current {
+			m.cursor = i
+		}
+	}
+
+	return m
+}
+
+func (m serverModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m serverModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case refreshMsg:
+		m.reload()
+	case tea.KeyMsg:
+		if closeHelp(&m.help, msg) {
+			return m, nil
+		}
+		m.err = nil
+		switch {
+		case key.Matches(msg, m.keyMap.CursorUp):
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case key.Matches(msg, m.keyMap.CursorDown):
+			if m.cursor < len(m.servers)-1 {
+				m.cursor++
+			}
+		case key.Matches(msg, m.keyMap.Enter):
+			if len(m.servers) == 0 {
+				break
+			}
+			return m, openServer(m.servers[m.cursor])
+		case key.Matches(msg, m.keyMap.Refresh):
+			m.reload()
+		case key.Matches(msg, m.keyMap.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, m.keyMap.Help):
+			m.help.ShowAll = true
+		}
+	}
+
+	return m, nil
+}
+
+// reload lists the servers again and keeps the cursor in bounds.
+func (m *serverModel) reload() {
+	m.servers, m.err = m.tmux.TmuxListServers()
+	m.cursor = min(m.cursor, max(len(m.servers)-1, 0))
+}
+
+func (m serverModel) View() string {
+	if m.help.ShowAll {
+		return m.styles.viewKeyOverlay(m.keyMap)
+	}
+	servers := list.New()
+	for i, server := range m.servers {
+		if i == m.cursor {
+			servers.Item(m.styles.selected.Render("> " + renderServer(server)))
+		} else if server.Alive {
+			servers.Item("  " + renderServer(server))
+		} else {
+			servers.Item(m.styles.dim.Render("  " + renderServer(server)))
+		}
+	}
+	servers = servers.Enumerator(blankEnumerator)
+
+	return fmt.Sprintf(
+		"%s\n%s",
+		m.styles.root.Render(
+			fmt.Sprintf(
+				"%s\n%s%s",
+				m.styles.header.Render("Servers:"),
+				m.styles.list.Render(servers.String()),
+				m.styles.renderStatus(m.err),
+			),
+		),
+		m.help.View(m.keyMap),
+	)
+}
+
+func renderServer(server Server) string {
+	current := ""
+	if server.Current {
+		current = " *"
+	}
+	if !server.Alive {
+		return fmt.Sprintf("%s%s (not running)", server.Name, current)
+	}
+	return fmt.Sprintf("%s%s: %d sessions", server.Name, current, server.Sessions)
+}
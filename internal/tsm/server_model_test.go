@@ -0,0 +1,64 @@
+package tsm
+
+import (
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestRootModelBrowsesOtherServers(t *testing.T) {
+	tmux := &MockTmux{
+		sessions: testSessions("test_session_1"),
+		servers: []Server{
+			{Name: "default", Path: "/tmp/tmux-1000/default", Alive: true, Sessions: 1, Current: true},
+			{Name: "work", Path: "/tmp/tmux-1000/work", Alive: true, Sessions: 2},
+		},
+		server_sessions: map[string][]Session{"/tmp/tmux-1000/work": testSessions("api", "web")},
+	}
+	var root tea.Model = InitialRootModel(tmux, Config{})
+
+	root, cmd := sendKeys(root, "h")
+	root, _ = root.Update(cmd())
+	if root.(rootModel).state != CHOOSING {
+		t.Fatalf("Expected state to be %d, got %d", CHOOSING, root.(rootModel).state)
+	}
+	if cursor := root.(rootModel).servers.(serverModel).cursor; cursor != 0 {
+		t.Errorf("Expected cursor to start on the current server, got %d", cursor)
+	}
+
+	root, cmd = sendKeys(root, "j", "enter")
+	root, _ = root.Update(cmd())
+	if root.(rootModel).state != SESSION_MANAGEMENT {
+		t.Fatalf("Expected state to be %d, got %d", SESSION_MANAGEMENT, root.(rootModel).state)
+	}
+	sessions := root.(rootModel).sessions.(model)
+	if names := sessionNames(sessions.sessions); !slices.Equal(names, []string{"api", "web"}) {
+		t.Errorf("Expected sessions of the work server, got %v", names)
+	}
+
+	// Going back lists the servers as seen from the one tsm started on.
+	root, cmd = sendKeys(root, "h")
+	root, _ = root.Update(cmd())
+	servers := root.(rootModel).servers.(serverModel)
+	if len(servers.servers) != 2 || !servers.servers[0].Current {
+		t.Errorf("Expected default to stay the current server, got %v", servers.servers)
+	}
+}
+
+func TestRenderServer(t *testing.T) {
+	tests := []struct {
+		server   Server
+		expected string
+	}{
+		{Server{Name: "default", Alive: true, Sessions: 3, Current: true}, "default *: 3 sessions"},
+		{Server{Name: "work", Alive: true, Sessions: 1}, "work: 1 sessions"},
+		{Server{Name: "/tmp/pairing.sock"}, "/tmp/pairing.sock (not running)"},
+	}
+
+	for _, test := range tests {
+		if rendered := renderServer(test.server); rendered != test.expected {
+			t.Errorf("Expected %q, got %q", test.expected, rendered)
+		}
+	}
+}
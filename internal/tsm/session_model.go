@@ -1,7 +1,10 @@
 package tsm
 
 import (
+	"errors"
 	"fmt"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/help"
@@ -10,17 +13,6 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/lipgloss/list"
-	"github.com/op/redlog/pkg/catppuccin"
-)
-
-var (
-	// TODO: tidy up styles
-	catppuccinStyle = catppuccin.Macchiato
-	rootStyle       = lipgloss.NewStyle().Background(catppuccinStyle.Base()).Width(50).Align(lipgloss.Center)
-	helpStyle       = lipgloss.NewStyle().Foreground(catppuccinStyle.Green()).Background(catppuccinStyle.Base()).Align(lipgloss.Left).Italic(true).Faint(true)
-	headerStyle     = lipgloss.NewStyle().Background(catppuccinStyle.Base()).Bold(true).PaddingTop(1).PaddingBottom(1).Width(30).Align(lipgloss.Center)
-	selectedStyle   = lipgloss.NewStyle().Foreground(catppuccinStyle.Mauve()).Background(catppuccinStyle.Base())
-	listStyle       = lipgloss.NewStyle().Background(catppuccinStyle.Base()).Width(30).Align(lipgloss.Left).BorderStyle(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("63"))
 )
 
 type (
@@ -32,11 +24,23 @@ const (
 	MANAGE_STATE State = iota
 	CREATE_STATE
 	RENAME_STATE
+	MOVE_STATE
+	JOIN_STATE
+	LAYOUT_STATE
+	CONFIRM_STATE
+	TAG_STATE
 )
 
 const (
 	NEW_SESSION_INPUT Input = iota
 	RENAME_SESSION_INPUT
+	LAYOUT_PATH_INPUT
+	NEW_SESSION_DIR_INPUT
+	NEW_SESSION_COMMAND_INPUT
+	TAGS_INPUT
+	// NEW_SESSION_ATTACH is the attach toggle of the create form, it has no
+	// text input of its own.
+	NEW_SESSION_ATTACH
 )
 
 type sessionKeymap struct {
@@ -51,7 +55,22 @@ type manageKeyMap struct {
 	Enter      key.Binding
 	Create     key.Binding
 	Rename     key.Binding
+	Windows    key.Binding
+	Servers    key.Binding
 	Filter     key.Binding
+	Mark       key.Binding
+	Invert     key.Binding
+	Range      key.Binding
+	Detach     key.Binding
+	Layout     key.Binding
+	Export     key.Binding
+	Undo       key.Binding
+	Tag        key.Binding
+	Collapse   key.Binding
+	Pin        key.Binding
+	PinTo      key.Binding
+	Jump       key.Binding
+	Clear      key.Binding
 	Quit       key.Binding
 	Help       key.Binding
 }
@@ -59,7 +78,9 @@ type manageKeyMap struct {
 func (km manageKeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		{km.CursorUp, km.CursorDown, km.Create, km.Delete, km.Enter, km.Rename},
-		{km.Filter, km.Quit},
+		{km.Windows, km.Servers, km.Filter, km.Quit},
+		{km.Tag, km.Collapse, km.Pin, km.PinTo, km.Jump},
+		{km.Mark, km.Invert, km.Range, km.Detach, km.Layout, km.Export, km.Undo, km.Clear},
 	}
 }
 
@@ -70,13 +91,15 @@ func (km manageKeyMap) ShortHelp() []key.Binding {
 }
 
 type filterKeyMap struct {
-	Enter  key.Binding
-	Escape key.Binding
+	CursorUp   key.Binding
+	CursorDown key.Binding
+	Enter      key.Binding
+	Escape     key.Binding
 }
 
 func (km filterKeyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
-		{km.Enter, km.Escape},
+		{km.CursorUp, km.CursorDown, km.Enter, km.Escape},
 	}
 }
 
@@ -85,13 +108,21 @@ func (km filterKeyMap) ShortHelp() []key.Binding {
 }
 
 var default_filtering_keys = filterKeyMap{
+	CursorUp: key.NewBinding(
+		key.WithKeys("ctrl+p"),
+		key.WithHelp("ctrl+p", "move up"),
+	),
+	CursorDown: key.NewBinding(
+		key.WithKeys("ctrl+n"),
+		key.WithHelp("ctrl+n", "move down"),
+	),
 	Enter: key.NewBinding(
 		key.WithKeys("enter"),
 		key.WithHelp("enter", "apply filter"),
 	),
 	Escape: key.NewBinding(
 		key.WithKeys("esc"),
-		key.WithHelp("esc", "reset search"),
+		key.WithHelp("esc", "clear search"),
 	),
 }
 
@@ -106,7 +137,7 @@ var default_manage_keys = manageKeyMap{
 	),
 	Delete: key.NewBinding(
 		key.WithKeys("d"),
-		key.WithHelp("d", "delete"),
+		key.WithHelp("d", "kill"),
 	),
 	Enter: key.NewBinding(
 		key.WithKeys("enter"),
@@ -120,10 +151,71 @@ var default_manage_keys = manageKeyMap{
 		key.WithKeys("r"),
 		key.WithHelp("r", "rename session"),
 	),
+	Windows: key.NewBinding(
+		key.WithKeys("l", "right"),
+		key.WithHelp("l/→", "windows"),
+	),
+	Servers: key.NewBinding(
+		key.WithKeys("h", "left"),
+		key.WithHelp("h/←", "servers"),
+	),
 	Filter: key.NewBinding(
 		key.WithKeys("/"),
 		key.WithHelp("/", "search"),
 	),
+	Mark: key.NewBinding(
+		key.WithKeys(" "),
+		key.WithHelp("space", "mark"),
+	),
+	Invert: key.NewBinding(
+		key.WithKeys("*"),
+		key.WithHelp("*", "invert marks"),
+	),
+	Range: key.NewBinding(
+		key.WithKeys("V"),
+		key.WithHelp("V", "mark range"),
+	),
+	Detach: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "detach clients"),
+	),
+	Layout: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "apply layout"),
+	),
+	Export: key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "export to snapshot"),
+	),
+	Undo: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "undo kill"),
+	),
+	Tag: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "tag sessions"),
+	),
+	Collapse: key.NewBinding(
+		key.WithKeys("z"),
+		key.WithHelp("z", "fold/unfold tag"),
+	),
+	Pin: key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "pin/unpin"),
+	),
+	// The slot of PinTo and Jump is the position of the key pressed.
+	PinTo: key.NewBinding(
+		key.WithKeys("alt+1", "alt+2", "alt+3", "alt+4", "alt+5", "alt+6", "alt+7", "alt+8", "alt+9"),
+		key.WithHelp("alt+1-9", "pin to slot"),
+	),
+	Jump: key.NewBinding(
+		key.WithKeys("1", "2", "3", "4", "5", "6", "7", "8", "9"),
+		key.WithHelp("1-9", "jump to pin"),
+	),
+	Clear: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "clear marks/search"),
+	),
 	Quit: key.NewBinding(
 		key.WithKeys("q", "ctrl+c"),
 		key.WithHelp("ctrl+c/q", "quit"),
@@ -134,8 +226,70 @@ var default_manage_keys = manageKeyMap{
 	),
 }
 
+// manageActions names the bindings of manageKeyMap for the keys section of
+// the config file.
+var manageActions = map[string]func(*manageKeyMap) *key.Binding{
+	"up":       func(km *manageKeyMap) *key.Binding { return &km.CursorUp },
+	"down":     func(km *manageKeyMap) *key.Binding { return &km.CursorDown },
+	"kill":     func(km *manageKeyMap) *key.Binding { return &km.Delete },
+	"switch":   func(km *manageKeyMap) *key.Binding { return &km.Enter },
+	"create":   func(km *manageKeyMap) *key.Binding { return &km.Create },
+	"rename":   func(km *manageKeyMap) *key.Binding { return &km.Rename },
+	"windows":  func(km *manageKeyMap) *key.Binding { return &km.Windows },
+	"servers":  func(km *manageKeyMap) *key.Binding { return &km.Servers },
+	"filter":   func(km *manageKeyMap) *key.Binding { return &km.Filter },
+	"mark":     func(km *manageKeyMap) *key.Binding { return &km.Mark },
+	"invert":   func(km *manageKeyMap) *key.Binding { return &km.Invert },
+	"range":    func(km *manageKeyMap) *key.Binding { return &km.Range },
+	"detach":   func(km *manageKeyMap) *key.Binding { return &km.Detach },
+	"layout":   func(km *manageKeyMap) *key.Binding { return &km.Layout },
+	"export":   func(km *manageKeyMap) *key.Binding { return &km.Export },
+	"undo":     func(km *manageKeyMap) *key.Binding { return &km.Undo },
+	"tag":      func(km *manageKeyMap) *key.Binding { return &km.Tag },
+	"collapse": func(km *manageKeyMap) *key.Binding { return &km.Collapse },
+	"pin":      func(km *manageKeyMap) *key.Binding { return &km.Pin },
+	"pin_to":   func(km *manageKeyMap) *key.Binding { return &km.PinTo },
+	"jump":     func(km *manageKeyMap) *key.Binding { return &km.Jump },
+	"clear":    func(km *manageKeyMap) *key.Binding { return &km.Clear },
+	"quit":     func(km *manageKeyMap) *key.Binding { return &km.Quit },
+	"help":     func(km *manageKeyMap) *key.Binding { return &km.Help },
+}
+
+// manageKeys is default_manage_keys with the actions rebound by keys.
+func manageKeys(keys map[string][]string) manageKeyMap {
+	km := default_manage_keys
+	for action, bound := range keys {
+		binding, ok := manageActions[action]
+		if !ok {
+			continue
+		}
+		*binding(&km) = key.NewBinding(
+			key.WithKeys(bound...),
+			key.WithHelp(strings.Join(bound, "/"), binding(&km).Help().Desc),
+		)
+	}
+	return km
+}
+
 type model struct {
-	choices         []string
+	sessions        []Session
+	projects        []Project
+	layouts         []Layout
+	snapshot        []SnapshotSession
+	choices         []choice
+	highlights      [][]int
+	marked          map[string]bool
+	collapsed       map[string]bool
+	ranging         bool
+	range_start     int
+	kill_targets    []killTarget
+	kill_protected  []string
+	create_attach   bool
+	trash           Trash
+	history         History
+	pins            Pins
+	current         string
+	filter          string
 	cursor          int
 	state           State
 	inputs          []textinput.Model
@@ -145,6 +299,13 @@ type model struct {
 	help            help.Model
 	sessKeyMap      sessionKeymap
 	tmux            Tmuxer
+	styles          Styles
+	config          Config
+	err             error
+	preview         string
+	preview_session string
+	width           int
+	height          int
 }
 
 func createSessionInputBubble(placeholder string) textinput.Model {
@@ -166,38 +327,149 @@ func createFilteringInputBubble() textinput.Model {
 	return filtering_input
 }
 
-func InitialSessionModel(tmux Tmuxer) model {
-	inputs := make([]textinput.Model, 2)
+func InitialSessionModel(tmux Tmuxer, config Config) model {
+	inputs := make([]textinput.Model, 6)
 	inputs[NEW_SESSION_INPUT] = createSessionInputBubble("New session name")
 	inputs[RENAME_SESSION_INPUT] = createSessionInputBubble("Rename session")
+	// Names are checked by validateInput, which says why one is too long
+	// rather than silently refusing to type.
+	inputs[NEW_SESSION_INPUT].CharLimit = 0
+	inputs[RENAME_SESSION_INPUT].CharLimit = 0
+	inputs[LAYOUT_PATH_INPUT] = createSessionInputBubble("Layout file")
+	inputs[LAYOUT_PATH_INPUT].CharLimit = 0
+	inputs[NEW_SESSION_DIR_INPUT] = createDirInputBubble()
+	inputs[NEW_SESSION_COMMAND_INPUT] = createSessionInputBubble("Shell")
+	inputs[NEW_SESSION_COMMAND_INPUT].CharLimit = 0
+	inputs[NEW_SESSION_COMMAND_INPUT].Width = 27
+	inputs[TAGS_INPUT] = createSessionInputBubble("work, api")
+	inputs[TAGS_INPUT].CharLimit = 0
 	filtering_input := createFilteringInputBubble()
 
-	choices := tmux.TmuxListSessions()
-	help := help.New()
-	help.ShowAll = false
-
-	return model{
-		choices:         choices,
+	sessions, err := tmux.TmuxListSessions()
+	// The history is read up front rather than in Init, the list would jump
+	// under the cursor if it arrived later.
+	history, historyErr := LoadHistory(config.HistoryPath)
+	pins, pinsErr := LoadPins(config.PinsPath)
+	if pinsErr != nil {
+		// Pins are only kept in memory rather than written over pins that
+		// could not be read.
+		config.PinsPath = ""
+	}
+	// So is the trash, a kill saves it and must not lose what is on disk.
+	trash, trashErr := LoadTrash(config.TrashPath)
+	if trashErr != nil {
+		// Kills are only kept in memory rather than written over a trash
+		// that could not be read.
+		config.TrashPath = ""
+	}
+	current, currentErr := tmux.TmuxCurrentSession()
+	err = errors.Join(err, historyErr, pinsErr, trashErr, currentErr)
+	SortSessions(sessions, config.SortOrder, history, current)
+	styles := configStyles(config)
+	help := newHelp(styles)
+
+	m := model{
+		sessions:        sessions,
+		marked:          map[string]bool{},
+		collapsed:       map[string]bool{},
 		state:           MANAGE_STATE,
 		inputs:          inputs,
-		filtering:       false,
+		filtering:       config.DefaultMode == FILTER_MODE,
 		filtering_input: filtering_input,
 		help:            help,
-		sessKeyMap:      sessionKeymap{ManageKeyMap: default_manage_keys, FilteringKeyMap: default_filtering_keys},
+		sessKeyMap:      sessionKeymap{ManageKeyMap: manageKeys(config.Keys), FilteringKeyMap: default_filtering_keys},
 		tmux:            tmux,
+		styles:          styles,
+		config:          config,
+		history:         history,
+		pins:            pins,
+		trash:           trash,
+		current:         current,
+		err:             err,
+	}
+	m.applyFilter(m.query())
+	// Frecency puts the session used before the current one right after it,
+	// start there so enter goes back.
+	if lead := m.frecencyLead(); len(lead) > 0 {
+		m.cursor = max(slices.IndexFunc(m.choices, func(c choice) bool { return c.name() == lead[1] }), 0)
 	}
+	// Init captures the preview of a session, anything else is described
+	// right away.
+	m.requestPreview()
+
+	return m
 }
 
 func (m model) Init() tea.Cmd {
-	return nil
+	var preview tea.Cmd
+	if len(m.preview_session) > 0 {
+		preview = capturePreview(m.tmux, m.preview_session)
+	}
+	return tea.Batch(preview, discoverProjects(m.config), discoverLayouts(), discoverSnapshot(m.config.SnapshotPath))
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case refreshMsg:
+		m.reloadSessions()
+		m.preview_session = ""
+		return m, m.requestPreview()
+	case projectsMsg:
+		m.projects = msg.projects
+		m.applyFilter(m.query())
+		return m, m.requestPreview()
+	case layoutsMsg:
+		m.layouts = msg.layouts
+		m.err = msg.err
+		m.applyFilter(m.query())
+		return m, m.requestPreview()
+	case bulkDoneMsg:
+		m.reloadSessions()
+		if msg.err != nil {
+			m.err = msg.err
+		}
+		return m, m.requestPreview()
+	case snapshotMsg:
+		m.snapshot = msg.sessions
+		m.err = msg.err
+		m.applyFilter(m.query())
+		return m, m.requestPreview()
+	case layoutAppliedMsg:
+		m.err = msg.err
+		if m.err == nil {
+			m.err = m.tmux.TmuxSwitchSession(msg.session)
+		}
+		if m.err == nil {
+			return m, tea.Quit
+		}
+		err := m.err
+		m.reloadSessions()
+		m.err = err
+		return m, m.requestPreview()
+	case previewMsg:
+		// Drop captures that arrive after the cursor has already moved on.
+		if msg.session == m.preview_session {
+			m.preview = msg.content
+			if msg.err != nil {
+				m.preview = msg.err.Error()
+			}
+		}
+		return m, nil
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+	}
+
 	switch m.state {
 	case MANAGE_STATE:
 		return m.updateManageState(msg)
-	case CREATE_STATE, RENAME_STATE:
+	case CREATE_STATE:
+		return m.updateCreateState(msg)
+	case RENAME_STATE, LAYOUT_STATE, TAG_STATE:
 		return m.updateInputState(msg)
+	case CONFIRM_STATE:
+		return m.updateConfirmState(msg)
 	}
 
 	return m, nil
@@ -205,68 +477,307 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (m model) updateManageState(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
+	if closeHelp(&m.help, msg) {
+		return m, nil
+	}
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		m.err = nil
 		if m.filtering {
 			m.filtering_input.Focus()
-			switch msg.String() {
-			case "enter":
-				value := m.filtering_input.Value()
-				var filtered_choices []string
-				for _, choice := range m.choices {
-					if strings.HasPrefix(choice, value) {
-						filtered_choices = append(filtered_choices, choice)
-					}
-				}
-				m.choices = filtered_choices
+			fkm := m.sessKeyMap.FilteringKeyMap
+			switch {
+			case key.Matches(msg, fkm.Enter):
+				m.filter = m.filtering_input.Value()
 				m.filtering = false
 				m.filtering_input.Reset()
-				return m, cmd
-			case "esc":
+				return m, m.requestPreview()
+			case key.Matches(msg, fkm.Escape):
+				m.filter = ""
 				m.filtering = false
 				m.filtering_input.Reset()
-				return m, cmd
+				m.applyFilter(m.filter)
+				return m, m.requestPreview()
+			case key.Matches(msg, fkm.CursorUp):
+				if m.cursor > 0 {
+					m.cursor--
+				}
+				return m, m.requestPreview()
+			case key.Matches(msg, fkm.CursorDown):
+				if m.cursor < len(m.choices)-1 {
+					m.cursor++
+				}
+				return m, m.requestPreview()
 			}
 			m.filtering_input, cmd = m.filtering_input.Update(msg)
+			m.applyFilter(m.filtering_input.Value())
+			m.cursor = 0
 		} else {
-			switch msg.String() {
-			case "ctrl+p", "k":
+			km := m.sessKeyMap.ManageKeyMap
+			switch {
+			case key.Matches(msg, km.CursorUp):
 				if m.cursor > 0 {
 					m.cursor--
 				}
-			case "ctrl+n", "j":
+			case key.Matches(msg, km.CursorDown):
 				if m.cursor < len(m.choices)-1 {
 					m.cursor++
 				}
-			case "d":
-				err := m.tmux.TmuxKillSession(m.choices[m.cursor])
-				if err == nil {
-					m.choices = append(m.choices[:m.cursor], m.choices[m.cursor+1:]...)
+			case key.Matches(msg, km.Delete):
+				m.confirmKill(m.targets())
+			case key.Matches(msg, km.Undo):
+				var restored []string
+				restored, m.err = m.undoKill()
+				err := m.err
+				m.reloadSessions()
+				if err != nil {
+					m.err = err
+				}
+				if len(restored) > 0 {
+					m.cursor = max(slices.IndexFunc(m.choices, func(c choice) bool { return c.name() == restored[0] }), 0)
+				}
+			case key.Matches(msg, km.Detach):
+				m.err = m.detachSessions(m.targets())
+			case key.Matches(msg, km.Layout):
+				if len(m.targets()) == 0 {
+					break
+				}
+				m.state = LAYOUT_STATE
+				m.focused = LAYOUT_PATH_INPUT
+				m.inputs[m.focused].SetValue(LayoutFileName)
+			case key.Matches(msg, km.Export):
+				if len(m.targets()) == 0 {
+					break
+				}
+				return m, exportSnapshot(m.tmux, m.config.SnapshotPath, m.targets())
+			case key.Matches(msg, km.Mark):
+				m.toggleMark()
+				if m.cursor < len(m.choices)-1 {
+					m.cursor++
+				}
+			case key.Matches(msg, km.Invert):
+				m.invertMarks()
+			case key.Matches(msg, km.Range):
+				m.toggleRange()
+			case key.Matches(msg, km.Enter):
+				if len(m.choices) == 0 {
+					break
+				}
+				selected := m.choices[m.cursor]
+				if selected.kind == LAYOUT_CHOICE {
+					return m, applyLayout(m.tmux, selected.layout)
+				}
+				if selected.kind == SNAPSHOT_CHOICE {
+					return m, restoreSession(m.tmux, selected.snapshot)
+				}
+				if selected.kind == PIN_CHOICE {
+					return m.jumpToPin(selected.slot)
+				}
+				if selected.kind == TAG_CHOICE {
+					m.toggleCollapse()
+					break
+				}
+				if selected.kind == PROJECT_CHOICE && hasLayout(selected.project) {
+					var layout Layout
+					layout, m.err = LoadLayout(filepath.Join(selected.project.Path, LayoutFileName))
+					if m.err != nil {
+						break
+					}
+					return m, applyLayout(m.tmux, layout)
+				}
+				if selected.kind == PROJECT_CHOICE {
+					m.err = m.tmux.TmuxCreateSession(CreateSessionOptions{Name: selected.project.Name, Dir: selected.project.Path})
+					if m.err != nil {
+						break
+					}
 				}
-			case "enter":
-				err := m.tmux.TmuxSwitchSession(m.choices[m.cursor])
-				if err == nil {
+				m.err = m.tmux.TmuxSwitchSession(selected.name())
+				if m.err == nil {
 					return m, tea.Quit
 				}
-			case "c":
+				if selected.kind == PROJECT_CHOICE {
+					// The session exists now even though we could not get to it.
+					err := m.err
+					m.reloadSessions()
+					m.err = err
+				}
+			case key.Matches(msg, km.Jump):
+				return m.jumpToPin(slices.Index(km.Jump.Keys(), msg.String()) + 1)
+			case key.Matches(msg, km.Tag):
+				m.startTagging()
+			case key.Matches(msg, km.Collapse):
+				m.toggleCollapse()
+			case key.Matches(msg, km.Pin):
+				m.togglePin()
+			case key.Matches(msg, km.PinTo):
+				m.pinTo(slices.Index(km.PinTo.Keys(), msg.String()) + 1)
+			case key.Matches(msg, km.Create):
 				m.state = CREATE_STATE
 				m.focused = NEW_SESSION_INPUT
-			case "r":
+			case key.Matches(msg, km.Rename):
+				if len(m.choices) == 0 || m.choices[m.cursor].kind != SESSION_CHOICE {
+					break
+				}
 				m.state = RENAME_STATE
 				m.focused = RENAME_SESSION_INPUT
-			case "/":
+			case key.Matches(msg, km.Windows):
+				if len(m.choices) == 0 || m.choices[m.cursor].kind != SESSION_CHOICE {
+					break
+				}
+				return m, openWindows(m.choices[m.cursor].session)
+			case key.Matches(msg, km.Servers):
+				return m, back
+			case key.Matches(msg, km.Filter):
 				m.filtering = true
-			case "esc":
-				m.choices = m.tmux.TmuxListSessions()
-			case "ctrl+c", "q":
+				m.filtering_input.SetValue(m.filter)
+			case key.Matches(msg, km.Clear):
+				// Back out of a range first, then the marks, then the filter.
+				switch {
+				case m.ranging:
+					m.ranging = false
+				case len(m.marked) > 0:
+					clear(m.marked)
+				default:
+					m.filter = ""
+					m.applyFilter(m.filter)
+					m.cursor = min(m.cursor, max(len(m.choices)-1, 0))
+				}
+			case key.Matches(msg, km.Quit):
 				return m, tea.Quit
-			case "?":
+			case key.Matches(msg, km.Help):
 				m.help.ShowAll = true
 			}
 		}
 	}
 
-	return m, cmd
+	return m, tea.Batch(cmd, m.requestPreview())
+}
+
+// query is the filter currently in effect, live while it is being typed.
+func (m model) query() string {
+	if m.filtering {
+		return m.filtering_input.Value()
+	}
+	return m.filter
+}
+
+// reloadSessions asks tmux for the sessions again and rebuilds the list.
+func (m *model) reloadSessions() {
+	m.sessions, m.err = m.tmux.TmuxListSessions()
+	SortSessions(m.sessions, m.config.SortOrder, m.history, m.current)
+	m.pruneMarks()
+	m.applyFilter(m.query())
+	m.cursor = min(m.cursor, max(len(m.choices)-1, 0))
+}
+
+// applyFilter merges pins, sessions, saved sessions, layouts and unopened
+// projects into the list, then ranks them against a fuzzy query and keeps
+// only the ones that match. tag: terms keep only the sessions with those
+// tags, and without any query the sessions are grouped by tag. The full
+// lists stay on the model so an empty query brings everything back without
+// asking tmux again.
+func (m *model) applyFilter(query string) {
+	all := sessionChoices(m.sessions)
+	all = append(all, snapshotChoices(m.snapshot, m.sessions)...)
+	all = append(all, layoutChoices(m.layouts, m.sessions)...)
+	all = append(all, projectChoices(m.projects, m.sessions)...)
+	// Pins go on top, in place of whatever else goes by their name.
+	all = slices.DeleteFunc(all, func(c choice) bool { return m.pins.SlotOf(c.name()) > 0 })
+	all = append(pinChoices(m.pins, m.sessions), all...)
+	tags, query := parseTagQuery(query)
+	if len(tags) > 0 {
+		all = slices.DeleteFunc(all, func(c choice) bool { return c.kind != SESSION_CHOICE || !HasTags(c.session, tags) })
+	} else if len(query) == 0 {
+		all = m.groupByTag(all)
+	}
+	if len(query) == 0 {
+		m.choices = m.previousAfterCurrent(all)
+		m.highlights = nil
+		return
+	}
+
+	type ranked struct {
+		choice    choice
+		score     int
+		positions []int
+	}
+	var matches []ranked
+	for _, c := range all {
+		if score, positions, ok := fuzzyMatch(query, c.name()); ok {
+			matches = append(matches, ranked{c, score, positions})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b ranked) int {
+		if a.score != b.score {
+			return b.score - a.score
+		}
+		return len(a.choice.name()) - len(b.choice.name())
+	})
+
+	m.choices = make([]choice, len(matches))
+	m.highlights = make([][]int, len(matches))
+	for i, match := range matches {
+		m.choices[i] = match.choice
+		m.highlights[i] = match.positions
+	}
+}
+
+// requestPreview schedules a capture of the selected session unless it is
+// already shown or on its way. The compact layout has no preview to show.
+func (m *model) requestPreview() tea.Cmd {
+	if m.config.Compact {
+		return nil
+	}
+	if m.cursor >= len(m.choices) {
+		m.preview = ""
+		m.preview_session = ""
+		return nil
+	}
+	selected := m.choices[m.cursor]
+	if selected.name() == m.preview_session {
+		return nil
+	}
+	m.preview_session = selected.name()
+	switch selected.kind {
+	case PROJECT_CHOICE:
+		m.preview = fmt.Sprintf("%s\n\nNo session yet, press enter to start one here.", selected.project.Path)
+		if hasLayout(selected.project) {
+			m.preview = fmt.Sprintf("%s\n\nNo session yet, press enter to build it from its %s.", selected.project.Path, LayoutFileName)
+		}
+		return nil
+	case LAYOUT_CHOICE:
+		m.preview = describeLayout(selected.layout)
+		return nil
+	case SNAPSHOT_CHOICE:
+		m.preview = describeLayout(snapshotLayout(selected.snapshot))
+		return nil
+	case PIN_CHOICE:
+		m.preview = fmt.Sprintf("%s\n\nNot running, press enter to start it here.", selected.pin.Dir)
+		return nil
+	case TAG_CHOICE:
+		m.preview = fmt.Sprintf("Sessions tagged %s:\n\n%s", selected.tag, strings.Join(m.tagMembers(selected.tag), "\n"))
+		return nil
+	}
+	return capturePreview(m.tmux, selected.name())
+}
+
+// previewSize fits the preview panel next to the list, falling back to a
+// sensible default until the terminal size is known.
+func (m model) previewSize() (int, int) {
+	if m.width == 0 || m.height == 0 {
+		return defaultPreviewWidth, defaultPreviewHeight
+	}
+	width := min(m.width-m.styles.root.GetWidth()-2, 2*defaultPreviewWidth)
+	height := min(m.height-4, defaultPreviewHeight)
+	return width, height
+}
+
+func (m model) viewPreview() string {
+	width, height := m.previewSize()
+	if m.config.Compact || width < minPreviewWidth || height <= 0 || len(m.choices) == 0 {
+		return ""
+	}
+	return m.styles.preview.Width(width).Height(height).Render(clipPreview(m.preview, width, height))
 }
 
 func (m model) updateInputState(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -275,39 +786,97 @@ func (m model) updateInputState(msg tea.Msg) (tea.Model, tea.Cmd) {
 	m.inputs[m.focused].Focus()
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.Type {
-		case tea.KeyEnter:
+		switch {
+		case key.Matches(msg, default_form_keys.Cancel):
+			m.state = MANAGE_STATE
+			m.inputs[m.focused].Reset()
+			return m, nil
+		case key.Matches(msg, default_form_keys.Submit):
 			sessionName := m.inputs[m.focused].Value()
+			if m.validateInput(m.focused) != nil {
+				return m, nil
+			}
 			switch m.focused {
-			case NEW_SESSION_INPUT:
-				err := m.tmux.TmuxCreateSession(sessionName)
-				if err == nil {
-					m.state = MANAGE_STATE
-					m.choices = m.tmux.TmuxListSessions()
-				}
 			case RENAME_SESSION_INPUT:
-				err := m.tmux.TmuxRenameSession(m.choices[m.cursor], sessionName)
-				if err == nil {
+				oldName := m.choices[m.cursor].name()
+				m.err = m.tmux.TmuxRenameSession(oldName, sessionName)
+				if m.err == nil {
 					m.state = MANAGE_STATE
-					m.choices = m.tmux.TmuxListSessions()
+					m.inputs[m.focused].Reset()
+					m.reloadSessions()
+					if m.err == nil && m.pins.SlotOf(oldName) > 0 {
+						m.pins.Rename(oldName, sessionName)
+						m.err = SavePins(m.config.PinsPath, m.pins)
+						m.applyFilter(m.query())
+					}
 				}
+			case LAYOUT_PATH_INPUT:
+				m.state = MANAGE_STATE
+				m.inputs[m.focused].Reset()
+				return m, applyLayoutTo(m.tmux, expandHome(sessionName), m.targets())
+			case TAGS_INPUT:
+				m.state = MANAGE_STATE
+				m.inputs[m.focused].Reset()
+				err := m.setTags(sessionName)
+				m.reloadSessions()
+				if err != nil {
+					m.err = err
+				}
+				return m, m.requestPreview()
 			}
 		}
 	}
 
 	m.inputs[m.focused], cmd = m.inputs[m.focused].Update(msg)
+	if m.focused == RENAME_SESSION_INPUT {
+		m.sanitizeInput()
+	}
 	return m, cmd
 }
 
+// sanitizeInput turns what is being typed into a session name when the
+// config asks for it.
+func (m *model) sanitizeInput() {
+	if !m.config.SanitizeSessionNames {
+		return
+	}
+	input := &m.inputs[m.focused]
+	if value := input.Value(); value != SessionNameFor(value) {
+		position := input.Position()
+		input.SetValue(SessionNameFor(value))
+		input.SetCursor(position)
+	}
+}
+
+// validateInput checks the session name or directory being typed, nil for
+// inputs that take anything.
+func (m model) validateInput(field Input) error {
+	switch field {
+	case NEW_SESSION_INPUT:
+		return ValidateSessionName(m.inputs[field].Value(), m.sessions, "", m.config.MaxSessionNameLength)
+	case NEW_SESSION_DIR_INPUT:
+		return validateDir(m.inputs[field].Value())
+	case RENAME_SESSION_INPUT:
+		return ValidateSessionName(m.inputs[field].Value(), m.sessions, m.choices[m.cursor].name(), m.config.MaxSessionNameLength)
+	}
+	return nil
+}
+
 func (m model) viewManageState() string {
 	choices := list.New()
 	for i, choice := range m.choices {
-		cursor := " "
+		var positions []int
+		if i < len(m.highlights) {
+			positions = m.highlights[i]
+		}
+		marker := "  "
+		if m.isMarked(i) {
+			marker = m.styles.mark.Render("● ")
+		}
 		if i == m.cursor {
-			cursor = ">"
-			choices.Item(selectedStyle.Render(fmt.Sprintf("%s %s", cursor, choice)))
+			choices.Item(m.styles.selected.Render("> ") + marker + m.styles.renderChoice(choice, positions, "", m.styles.selected))
 		} else {
-			choices.Item(fmt.Sprintf("%s %s", cursor, choice))
+			choices.Item("  " + marker + m.styles.renderChoice(choice, positions, "", lipgloss.NewStyle()))
 		}
 	}
 
@@ -317,51 +886,96 @@ func (m model) viewManageState() string {
 	if m.filtering {
 		return fmt.Sprintf(
 			"%s\n%s",
-			rootStyle.Render(
-				fmt.Sprintf(
-					"%s\n%s\n%s",
-					headerStyle.Render("Sessions:"),
-					listStyle.Render(choices.String()),
-					m.filtering_input.View(),
+			lipgloss.JoinHorizontal(
+				lipgloss.Top,
+				m.styles.root.Render(
+					fmt.Sprintf(
+						"%s\n%s\n%s%s",
+						m.styles.header.Render(m.header()),
+						m.styles.list.Render(choices.String()),
+						m.filtering_input.View(),
+						m.styles.renderStatus(m.err),
+					),
 				),
+				m.viewPreview(),
 			),
 			m.help.View(m.sessKeyMap.FilteringKeyMap),
 		)
 	} else {
 		return fmt.Sprintf(
 			"%s\n%s",
-			rootStyle.Render(
-				fmt.Sprintf("%s\n%s", headerStyle.Render("Sessions:"), listStyle.Render(choices.String())),
+			lipgloss.JoinHorizontal(
+				lipgloss.Top,
+				m.styles.root.Render(
+					fmt.Sprintf("%s\n%s%s", m.styles.header.Render(m.header()), m.styles.list.Render(choices.String()), m.styles.renderStatus(m.err)),
+				),
+				m.viewPreview(),
 			),
 			m.help.View(m.sessKeyMap.ManageKeyMap),
 		)
 	}
 }
 
+func (m model) header() string {
+	header := "Sessions"
+	if len(m.filter) > 0 {
+		header = fmt.Sprintf("Sessions matching %q", m.filter)
+	}
+	if marked := len(m.marked); marked > 0 {
+		header = fmt.Sprintf("%s (%d marked)", header, marked)
+	}
+	return header + ":"
+}
+
 func (m model) viewInputState() string {
 	var actionString string
 	switch m.focused {
-	case NEW_SESSION_INPUT:
-		actionString = "Create session:"
 	case RENAME_SESSION_INPUT:
 		actionString = "Rename session:"
+	case LAYOUT_PATH_INPUT:
+		actionString = fmt.Sprintf("Apply layout to %d sessions:", len(m.targets()))
+	case TAGS_INPUT:
+		actionString = fmt.Sprintf("Tag %d sessions:", len(m.targets()))
+	}
+
+	// The name is only judged once something has been typed.
+	var invalid error
+	if len(m.inputs[m.focused].Value()) > 0 {
+		invalid = m.validateInput(m.focused)
 	}
 
-	return rootStyle.Render(
+	return m.styles.root.Render(
 		fmt.Sprintf(
-			"%s\n%s",
-			headerStyle.Render(actionString),
+			"%s\n%s%s%s",
+			m.styles.header.Render(actionString),
 			m.inputs[m.focused].View(),
+			m.styles.renderStatus(invalid),
+			m.styles.renderStatus(m.err),
 		),
 	)
 }
 
+// renderStatus renders the last tmux error, if any, as a line below the view.
+func (s Styles) renderStatus(err error) string {
+	if err == nil {
+		return ""
+	}
+	return "\n" + s.error.Render(err.Error())
+}
+
 func (m model) View() string {
+	if m.help.ShowAll {
+		return m.styles.viewKeyOverlay(m.sessKeyMap.ManageKeyMap)
+	}
 	switch m.state {
 	case MANAGE_STATE:
 		return m.viewManageState()
-	case CREATE_STATE, RENAME_STATE:
+	case CREATE_STATE:
+		return m.viewCreateState()
+	case RENAME_STATE, LAYOUT_STATE, TAG_STATE:
 		return m.viewInputState()
+	case CONFIRM_STATE:
+		return m.viewConfirmState()
 	default:
 		return m.viewManageState()
 	}
@@ -1,52 +1,379 @@
 package tsm
 
 import (
+	"errors"
+	"fmt"
+	"os"
 	"slices"
 	"testing"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+// TestMain keeps the snapshots and the trash of DefaultConfig away from the
+// ones of whoever runs the tests.
+func TestMain(m *testing.M) {
+	state, err := os.MkdirTemp("", "tsm-state-*")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("XDG_STATE_HOME", state)
+	code := m.Run()
+	os.RemoveAll(state)
+	os.Exit(code)
+}
+
 type MockTmux struct {
-	sessions            []string
+	sessions            []Session
 	active_session      string
 	last_killed_session string
+	windows             map[string][]Window
+	panes               map[string][]Pane
+	zoomed_pane         string
+	environment         map[string]string
+	sent_keys           []string
+	layouts             map[string]string
+	respawned_panes     []string
+	servers             []Server
+	server_sessions     map[string][]Session
+	created             []CreateSessionOptions
+	next_id             int
+	err                 error
 }
 
-func (tmux *MockTmux) TmuxListSessions() []string {
-	return tmux.sessions
+func (tmux *MockTmux) nextId(prefix string) string {
+	tmux.next_id++
+	return fmt.Sprintf("%s%d", prefix, 99+tmux.next_id)
+}
+
+func (tmux *MockTmux) TmuxListSessions() ([]Session, error) {
+	return tmux.sessions, nil
 }
 
 func (tmux *MockTmux) TmuxKillSession(session string) error {
-	var alive_sessions []string
+	if tmux.err != nil {
+		return tmux.err
+	}
+	var alive_sessions []Session
 	for _, alive_session := range tmux.sessions {
-		if alive_session != session {
+		if alive_session.Name != session {
 			alive_sessions = append(alive_sessions, alive_session)
 		} else {
-			tmux.last_killed_session = alive_session
+			tmux.last_killed_session = alive_session.Name
 		}
 	}
 	tmux.sessions = alive_sessions
+	delete(tmux.windows, session)
 
 	return nil
 }
 
+func (tmux *MockTmux) TmuxDetachClients(session string) error {
+	if tmux.err != nil {
+		return tmux.err
+	}
+	idx := slices.IndexFunc(tmux.sessions, func(s Session) bool { return s.Name == session })
+	tmux.sessions[idx].Attached = 0
+	return nil
+}
+
 func (tmux *MockTmux) TmuxSwitchSession(session string) error {
+	if tmux.err != nil {
+		return tmux.err
+	}
 	tmux.active_session = session
 	return nil
 }
 
-func (tmux *MockTmux) TmuxCreateSession(session string) error {
-	tmux.sessions = append(tmux.sessions, session)
+func (tmux *MockTmux) TmuxSetTags(session string, tags []string) error {
+	if tmux.err != nil {
+		return tmux.err
+	}
+	idx := slices.IndexFunc(tmux.sessions, func(s Session) bool { return s.Name == session })
+	if idx < 0 {
+		return ErrSessionNotFound
+	}
+	tmux.sessions[idx].Tags = tags
+	return nil
+}
+
+func (tmux *MockTmux) TmuxCurrentSession() (string, error) {
+	return tmux.active_session, nil
+}
+
+func (tmux *MockTmux) TmuxCreateSession(opts CreateSessionOptions) error {
+	if tmux.err != nil {
+		return tmux.err
+	}
+	tmux.sessions = append(tmux.sessions, Session{Id: fmt.Sprintf("$%d", len(tmux.sessions)), Name: opts.Name, Windows: 1, Path: opts.Dir})
+	tmux.created = append(tmux.created, opts)
+	if tmux.windows != nil {
+		tmux.TmuxCreateWindow(opts.Name, "zsh", opts.Dir)
+	}
 	return nil
 }
 
 func (tmux *MockTmux) TmuxRenameSession(oldSession string, session string) error {
-	idx := slices.Index(tmux.sessions, oldSession)
-	tmux.sessions[idx] = session
+	if tmux.err != nil {
+		return tmux.err
+	}
+	idx := slices.IndexFunc(tmux.sessions, func(s Session) bool { return s.Name == oldSession })
+	tmux.sessions[idx].Name = session
+	return nil
+}
+
+func (tmux *MockTmux) TmuxListWindows(session string) ([]Window, error) {
+	if tmux.err != nil {
+		return nil, tmux.err
+	}
+	return tmux.windows[session], nil
+}
+
+func (tmux *MockTmux) TmuxCreateWindow(session string, name string, dir string) (string, error) {
+	if tmux.err != nil {
+		return "", tmux.err
+	}
+	windows := tmux.windows[session]
+	index := 0
+	if len(windows) > 0 {
+		index = windows[len(windows)-1].Index + 1
+	}
+	id := tmux.nextId("@")
+	tmux.windows[session] = append(windows, Window{Id: id, Index: index, Name: name, Panes: 1})
+	if tmux.panes != nil {
+		tmux.panes[id] = []Pane{{Id: tmux.nextId("%"), Command: "zsh", Path: dir, Width: 80, Height: 24, Active: true}}
+	}
+	return id, nil
+}
+
+func (tmux *MockTmux) findWindow(window string) (string, int) {
+	for session, windows := range tmux.windows {
+		for i := range windows {
+			if windows[i].Id == window {
+				return session, i
+			}
+		}
+	}
+	return "", -1
+}
+
+func (tmux *MockTmux) TmuxRenameWindow(window string, name string) error {
+	if tmux.err != nil {
+		return tmux.err
+	}
+	session, idx := tmux.findWindow(window)
+	tmux.windows[session][idx].Name = name
+	return nil
+}
+
+func (tmux *MockTmux) TmuxKillWindow(window string) error {
+	if tmux.err != nil {
+		return tmux.err
+	}
+	session, idx := tmux.findWindow(window)
+	tmux.windows[session] = slices.Delete(tmux.windows[session], idx, idx+1)
 	return nil
 }
 
+func (tmux *MockTmux) TmuxSelectWindow(window string) error {
+	if tmux.err != nil {
+		return tmux.err
+	}
+	session, idx := tmux.findWindow(window)
+	for i := range tmux.windows[session] {
+		tmux.windows[session][i].Active = i == idx
+	}
+	return nil
+}
+
+func (tmux *MockTmux) TmuxSwapWindow(src string, dst string) error {
+	if tmux.err != nil {
+		return tmux.err
+	}
+	session, srcIdx := tmux.findWindow(src)
+	_, dstIdx := tmux.findWindow(dst)
+	windows := tmux.windows[session]
+	windows[srcIdx].Index, windows[dstIdx].Index = windows[dstIdx].Index, windows[srcIdx].Index
+	windows[srcIdx], windows[dstIdx] = windows[dstIdx], windows[srcIdx]
+	return nil
+}
+
+func (tmux *MockTmux) TmuxMoveWindow(window string, session string, index int) error {
+	if tmux.err != nil {
+		return tmux.err
+	}
+	_, idx := tmux.findWindow(window)
+	tmux.windows[session][idx].Index = index
+	slices.SortFunc(tmux.windows[session], func(a, b Window) int { return a.Index - b.Index })
+	return nil
+}
+
+func (tmux *MockTmux) TmuxCapturePane(target string) (string, error) {
+	if tmux.err != nil {
+		return "", tmux.err
+	}
+	return "contents of " + target, nil
+}
+
+func (tmux *MockTmux) TmuxListServers() ([]Server, error) {
+	if tmux.err != nil {
+		return nil, tmux.err
+	}
+	return tmux.servers, nil
+}
+
+func (tmux *MockTmux) TmuxOnServer(server Server) Tmuxer {
+	return &MockTmux{sessions: tmux.server_sessions[server.Path]}
+}
+
+func (tmux *MockTmux) TmuxCaptureHistory(pane string) (string, error) {
+	if tmux.err != nil {
+		return "", tmux.err
+	}
+	return "history of " + pane, nil
+}
+
+func (tmux *MockTmux) TmuxSetEnvironment(session string, name string, value string) error {
+	if tmux.err != nil {
+		return tmux.err
+	}
+	tmux.environment[session+":"+name] = value
+	return nil
+}
+
+func (tmux *MockTmux) TmuxSendKeys(pane string, command string) error {
+	if tmux.err != nil {
+		return tmux.err
+	}
+	tmux.sent_keys = append(tmux.sent_keys, pane+" "+command)
+	return nil
+}
+
+func (tmux *MockTmux) TmuxSelectLayout(window string, layout string) error {
+	if tmux.err != nil {
+		return tmux.err
+	}
+	tmux.layouts[window] = layout
+	return nil
+}
+
+func (tmux *MockTmux) TmuxRespawnPane(pane string, dir string) error {
+	if tmux.err != nil {
+		return tmux.err
+	}
+	tmux.respawned_panes = append(tmux.respawned_panes, pane)
+	return nil
+}
+
+func (tmux *MockTmux) findPane(pane string) (string, int) {
+	for window, panes := range tmux.panes {
+		for i := range panes {
+			if panes[i].Id == pane {
+				return window, i
+			}
+		}
+	}
+	return "", -1
+}
+
+func (tmux *MockTmux) TmuxListPanes(window string) ([]Pane, error) {
+	if tmux.err != nil {
+		return nil, tmux.err
+	}
+	return tmux.panes[window], nil
+}
+
+func (tmux *MockTmux) TmuxSelectPane(pane string) error {
+	if tmux.err != nil {
+		return tmux.err
+	}
+	window, idx := tmux.findPane(pane)
+	for i := range tmux.panes[window] {
+		tmux.panes[window][i].Active = i == idx
+	}
+	return nil
+}
+
+func (tmux *MockTmux) TmuxKillPane(pane string) error {
+	if tmux.err != nil {
+		return tmux.err
+	}
+	window, idx := tmux.findPane(pane)
+	tmux.panes[window] = slices.Delete(tmux.panes[window], idx, idx+1)
+	return nil
+}
+
+func (tmux *MockTmux) TmuxSplitPane(pane string, horizontal bool, dir string) (string, error) {
+	if tmux.err != nil {
+		return "", tmux.err
+	}
+	window, idx := tmux.findPane(pane)
+	panes := tmux.panes[window]
+	id := tmux.nextId("%")
+	split := Pane{Id: id, Index: len(panes), Command: "zsh", Path: dir, Width: panes[idx].Width, Height: panes[idx].Height}
+	if horizontal {
+		split.Width /= 2
+	} else {
+		split.Height /= 2
+	}
+	tmux.panes[window] = append(panes, split)
+	return id, nil
+}
+
+func (tmux *MockTmux) TmuxBreakPane(pane string) error {
+	if tmux.err != nil {
+		return tmux.err
+	}
+	window, idx := tmux.findPane(pane)
+	broken := tmux.panes[window][idx]
+	tmux.panes[window] = slices.Delete(tmux.panes[window], idx, idx+1)
+	tmux.panes["@broken"] = []Pane{broken}
+	return nil
+}
+
+func (tmux *MockTmux) TmuxJoinPane(src string, dst string) error {
+	if tmux.err != nil {
+		return tmux.err
+	}
+	srcWindow, srcIdx := tmux.findPane(src)
+	dstWindow, _ := tmux.findPane(dst)
+	joined := tmux.panes[srcWindow][srcIdx]
+	tmux.panes[srcWindow] = slices.Delete(tmux.panes[srcWindow], srcIdx, srcIdx+1)
+	tmux.panes[dstWindow] = append(tmux.panes[dstWindow], joined)
+	return nil
+}
+
+func (tmux *MockTmux) TmuxZoomPane(pane string) error {
+	if tmux.err != nil {
+		return tmux.err
+	}
+	if tmux.zoomed_pane == pane {
+		tmux.zoomed_pane = ""
+	} else {
+		tmux.zoomed_pane = pane
+	}
+	return nil
+}
+
+func (tmux *MockTmux) TmuxSwapPane(src string, dst string) error {
+	if tmux.err != nil {
+		return tmux.err
+	}
+	window, srcIdx := tmux.findPane(src)
+	_, dstIdx := tmux.findPane(dst)
+	panes := tmux.panes[window]
+	panes[srcIdx], panes[dstIdx] = panes[dstIdx], panes[srcIdx]
+	return nil
+}
+
+func testSessions(names ...string) []Session {
+	sessions := make([]Session, len(names))
+	for i, name := range names {
+		sessions[i] = Session{Id: fmt.Sprintf("$%d", i), Name: name, Windows: 1}
+	}
+	return sessions
+}
+
 func TestCursorMovedInRightDirectionInManageState(t *testing.T) {
 	tests := []struct {
 		initial_pos  int
@@ -77,7 +404,7 @@ func TestCursorMovedInRightDirectionInManageState(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		test_model := InitialSessionModel(&MockTmux{sessions: []string{"test_session_1", "test_session_2", "test_session_3"}})
+		test_model := InitialSessionModel(&MockTmux{sessions: testSessions("test_session_1", "test_session_2", "test_session_3")}, DefaultConfig())
 		test_model.cursor = test.initial_pos
 		test_model.state = MANAGE_STATE
 		for i := 0; i < test.n_emit; i++ {
@@ -130,12 +457,11 @@ func TestTmuxKillSessionInManageState(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		test_model := InitialSessionModel(&MockTmux{sessions: []string{"test_session_1", "test_session_2", "test_session_3"}})
+		test_model := InitialSessionModel(&MockTmux{sessions: testSessions("test_session_1", "test_session_2", "test_session_3")}, DefaultConfig())
 		test_model.state = MANAGE_STATE
 		for _, kill_session_cursor := range test.kill_sessions {
 			test_model.cursor = kill_session_cursor
-			msg := tea.Key{Type: tea.KeyRunes, Runes: []rune{'d'}}
-			updModel, _ := test_model.Update(tea.KeyMsg(msg))
+			updModel, _ := sendKeys(test_model, "d", "y")
 			test_model = updModel.(model)
 		}
 		mockTmux := test_model.tmux.(*MockTmux)
@@ -146,8 +472,8 @@ func TestTmuxKillSessionInManageState(t *testing.T) {
 			t.Errorf("Expected sessions to be %v, got %v", test.expected_sessions, mockTmux.sessions)
 		}
 		for i := range len(test.expected_sessions) {
-			if mockTmux.sessions[i] != test.expected_sessions[i] {
-				t.Errorf("Expected session %s, got %s", test.expected_sessions[i], mockTmux.sessions[i])
+			if mockTmux.sessions[i].Name != test.expected_sessions[i] {
+				t.Errorf("Expected session %s, got %s", test.expected_sessions[i], mockTmux.sessions[i].Name)
 			}
 		}
 	}
@@ -181,7 +507,7 @@ func TestTmuxSwitchSessionInManageState(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		test_model := InitialSessionModel(&MockTmux{sessions: []string{"test_session_1", "test_session_2", "test_session_3"}})
+		test_model := InitialSessionModel(&MockTmux{sessions: testSessions("test_session_1", "test_session_2", "test_session_3")}, DefaultConfig())
 		test_model.state = MANAGE_STATE
 
 		for _, switch_session := range test.switch_sessions {
@@ -198,7 +524,7 @@ func TestTmuxSwitchSessionInManageState(t *testing.T) {
 }
 
 func TestTransitionToCreateState(t *testing.T) {
-	test_model := InitialSessionModel(&MockTmux{sessions: []string{"test_session_1", "test_session_2", "test_session_3"}})
+	test_model := InitialSessionModel(&MockTmux{sessions: testSessions("test_session_1", "test_session_2", "test_session_3")}, DefaultConfig())
 	test_model.state = MANAGE_STATE
 	msg := tea.Key{Type: tea.KeyRunes, Runes: []rune{'c'}}
 	updModel, _ := test_model.Update(tea.KeyMsg(msg))
@@ -213,7 +539,7 @@ func TestTransitionToCreateState(t *testing.T) {
 }
 
 func TestTransitionToRenameState(t *testing.T) {
-	test_model := InitialSessionModel(&MockTmux{sessions: []string{"test_session_1", "test_session_2", "test_session_3"}})
+	test_model := InitialSessionModel(&MockTmux{sessions: testSessions("test_session_1", "test_session_2", "test_session_3")}, DefaultConfig())
 	test_model.state = MANAGE_STATE
 	msg := tea.Key{Type: tea.KeyRunes, Runes: []rune{'r'}}
 	updModel, _ := test_model.Update(tea.KeyMsg(msg))
@@ -228,7 +554,7 @@ func TestTransitionToRenameState(t *testing.T) {
 }
 
 func TestTransitionToFilteringInManageState(t *testing.T) {
-	test_model := InitialSessionModel(&MockTmux{sessions: []string{"test_session_1", "test_session_2", "test_session_3"}})
+	test_model := InitialSessionModel(&MockTmux{sessions: testSessions("test_session_1", "test_session_2", "test_session_3")}, DefaultConfig())
 	test_model.state = MANAGE_STATE
 	msg := tea.Key{Type: tea.KeyRunes, Runes: []rune{'/'}}
 	updModel, _ := test_model.Update(tea.KeyMsg(msg))
@@ -246,7 +572,7 @@ func TestQuitApp(t *testing.T) {
 	tests := [][]rune{{'q'}, {'c', 't', 'r', 'l', '+', 'c'}}
 
 	for _, test := range tests {
-		test_model := InitialSessionModel(&MockTmux{sessions: []string{"test_session_1", "test_session_2", "test_session_3"}})
+		test_model := InitialSessionModel(&MockTmux{sessions: testSessions("test_session_1", "test_session_2", "test_session_3")}, DefaultConfig())
 		test_model.state = MANAGE_STATE
 		msg := tea.Key{Type: tea.KeyRunes, Runes: test}
 		_, cmd := test_model.Update(tea.KeyMsg(msg))
@@ -269,7 +595,7 @@ func TestUpdateFilteringInput(t *testing.T) {
 			true,
 		},
 		{
-			[][]rune{{'t'}, {'e'}, {'s'}, {'t'}, {'e', 'n', 't', 'e', 'r'}}, // TODO: here need to additinally check "choices"
+			[][]rune{{'t'}, {'e'}, {'s'}, {'t'}, {'e', 'n', 't', 'e', 'r'}},
 			"",
 			false,
 		},
@@ -281,7 +607,7 @@ func TestUpdateFilteringInput(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		test_model := InitialSessionModel(&MockTmux{sessions: []string{"test_session_1", "test_session_2", "test_session_3"}})
+		test_model := InitialSessionModel(&MockTmux{sessions: testSessions("test_session_1", "test_session_2", "test_session_3")}, DefaultConfig())
 		test_model.state = MANAGE_STATE
 		test_model.filtering = true
 
@@ -298,3 +624,110 @@ func TestUpdateFilteringInput(t *testing.T) {
 		}
 	}
 }
+
+func TestTmuxErrorsAreSurfacedInManageState(t *testing.T) {
+	tests := []struct {
+		key_runes         []rune
+		expected_sessions int
+	}{
+		{[]rune{'d'}, 3},
+		{[]rune{'e', 'n', 't', 'e', 'r'}, 3},
+	}
+
+	for _, test := range tests {
+		tmuxErr := &TmuxError{Args: []string{"kill-session"}, Stderr: "can't find session: test_session_1", Err: ErrSessionNotFound}
+		test_model := InitialSessionModel(&MockTmux{sessions: testSessions("test_session_1", "test_session_2", "test_session_3"), err: tmuxErr}, DefaultConfig())
+		test_model.state = MANAGE_STATE
+		msg := tea.Key{Type: tea.KeyRunes, Runes: test.key_runes}
+		updModel, cmd := test_model.Update(tea.KeyMsg(msg))
+		test_model = updModel.(model)
+
+		if !errors.Is(test_model.err, ErrSessionNotFound) {
+			t.Errorf("Expected error to be %v, got %v", ErrSessionNotFound, test_model.err)
+		}
+		if len(test_model.choices) != test.expected_sessions {
+			t.Errorf("Expected %d sessions, got %d", test.expected_sessions, len(test_model.choices))
+		}
+		if cmd != nil {
+			t.Errorf("Expected no command after a failed tmux call, got %v", cmd())
+		}
+	}
+}
+
+func TestPreviewFollowsCursor(t *testing.T) {
+	test_model := InitialSessionModel(&MockTmux{sessions: testSessions("test_session_1", "test_session_2", "test_session_3")}, Config{})
+	initMsg := test_model.Init()()
+	if initMsg.(previewMsg).session != "test_session_1" {
+		t.Fatalf("Expected initial preview of test_session_1, got %s", initMsg.(previewMsg).session)
+	}
+
+	updModel, cmd := test_model.Update(tea.KeyMsg(tea.Key{Type: tea.KeyRunes, Runes: []rune{'j'}}))
+	if cmd == nil {
+		t.Fatalf("Expected moving the cursor to request a preview")
+	}
+	moveMsg := cmd()
+
+	// The capture for the previous selection arrives late and must be dropped.
+	updModel, _ = updModel.Update(initMsg)
+	if updModel.(model).preview != "" {
+		t.Errorf("Expected stale preview to be ignored, got %q", updModel.(model).preview)
+	}
+	updModel, _ = updModel.Update(moveMsg)
+	if updModel.(model).preview != "contents of =test_session_2:" {
+		t.Errorf("Expected preview of test_session_2, got %q", updModel.(model).preview)
+	}
+
+	_, cmd = updModel.Update(tea.KeyMsg(tea.Key{Type: tea.KeyRunes, Runes: []rune{'?'}}))
+	if cmd != nil {
+		t.Errorf("Expected no new capture while the selection is unchanged")
+	}
+}
+
+func sessionNames(sessions []Session) []string {
+	names := make([]string, len(sessions))
+	for i, session := range sessions {
+		names[i] = session.Name
+	}
+	return names
+}
+
+func choiceNames(choices []choice) []string {
+	names := make([]string, len(choices))
+	for i, c := range choices {
+		names[i] = c.name()
+	}
+	return names
+}
+
+func TestFuzzyFilterIsLiveAndPreservesSessions(t *testing.T) {
+	test_model := InitialSessionModel(&MockTmux{sessions: testSessions("dotfiles", "work-api", "website", "tsm")}, DefaultConfig())
+	updModel, _ := sendKeys(test_model, "/", "w", "a")
+	if names := choiceNames(updModel.(model).choices); !slices.Equal(names, []string{"work-api"}) {
+		t.Errorf("Expected live results [work-api], got %v", names)
+	}
+
+	updModel, _ = sendKeys(updModel, "enter")
+	if names := choiceNames(updModel.(model).choices); !slices.Equal(names, []string{"work-api"}) {
+		t.Errorf("Expected applied filter to keep [work-api], got %v", names)
+	}
+	if len(updModel.(model).sessions) != 4 {
+		t.Errorf("Expected the full list to be kept underneath, got %v", sessionNames(updModel.(model).sessions))
+	}
+
+	updModel, _ = sendKeys(updModel, "esc")
+	if names := choiceNames(updModel.(model).choices); !slices.Equal(names, []string{"dotfiles", "work-api", "website", "tsm"}) {
+		t.Errorf("Expected esc to restore the original order, got %v", names)
+	}
+}
+
+func TestFuzzyFilterRanksBoundaryMatchesFirst(t *testing.T) {
+	test_model := InitialSessionModel(&MockTmux{sessions: testSessions("pastebin", "pets", "api-server", "project-search")}, DefaultConfig())
+	updModel, _ := sendKeys(test_model, "/", "p", "s")
+	names := choiceNames(updModel.(model).choices)
+	if len(names) != 4 || names[0] != "project-search" || names[3] != "api-server" {
+		t.Errorf("Expected project-search first and api-server last, got %v", names)
+	}
+	if !slices.Equal(updModel.(model).highlights[0], []int{0, 8}) {
+		t.Errorf("Expected highlights at word boundaries, got %v", updModel.(model).highlights[0])
+	}
+}
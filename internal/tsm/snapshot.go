@@ -0,0 +1,292 @@
+package tsm
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// SnapshotVersion is bumped whenever the snapshot format changes in a way
+// older versions of tsm cannot read.
+const SnapshotVersion = 1
+
+// Snapshot is everything needed to bring a tmux server back after a restart.
+type Snapshot struct {
+	Version  int               `json:"version"`
+	Created  time.Time         `json:"created"`
+	Sessions []SnapshotSession `json:"sessions"`
+}
+
+type SnapshotSession struct {
+	Name    string           `json:"name"`
+	Path    string           `json:"path"`
+	Tags    []string         `json:"tags,omitempty"`
+	Windows []SnapshotWindow `json:"windows"`
+}
+
+type SnapshotWindow struct {
+	Name   string         `json:"name"`
+	Layout string         `json:"layout"`
+	Active bool           `json:"active"`
+	Panes  []SnapshotPane `json:"panes"`
+}
+
+type SnapshotPane struct {
+	Path       string `json:"path"`
+	Command    string `json:"command"`
+	Active     bool   `json:"active"`
+	Scrollback string `json:"scrollback,omitempty"`
+}
+
+// shells are not worth restarting, a new pane comes with one anyway.
+var shells = []string{"sh", "bash", "zsh", "fish", "dash", "ksh", "tcsh", "nu"}
+
+// stateDir is where tsm keeps what it remembers between runs, under
+// $XDG_STATE_HOME or ~/.local/state.
+func stateDir() string {
+	state := os.Getenv("XDG_STATE_HOME")
+	if len(state) == 0 {
+		state = expandHome("~/.local/state")
+	}
+	return filepath.Join(state, "tsm")
+}
+
+// DefaultSnapshotPath is where snapshots live unless told otherwise.
+func DefaultSnapshotPath() string {
+	return filepath.Join(stateDir(), "snapshot.json")
+}
+
+// TakeSnapshot records the named sessions, or every session on the server
+// when no names are given. Scrollback makes the snapshot a lot bigger, so it
+// is only captured when asked for.
+func TakeSnapshot(tmux Tmuxer, scrollback bool, names ...string) (Snapshot, error) {
+	snapshot := Snapshot{Version: SnapshotVersion, Created: time.Now()}
+	sessions, err := tmux.TmuxListSessions()
+	if err != nil {
+		return snapshot, err
+	}
+	for _, session := range sessions {
+		if len(names) > 0 && !slices.Contains(names, session.Name) {
+			continue
+		}
+		saved := SnapshotSession{Name: session.Name, Path: session.Path, Tags: session.Tags}
+		windows, err := tmux.TmuxListWindows(session.Name)
+		if err != nil {
+			return snapshot, err
+		}
+		for _, window := range windows {
+			savedWindow := SnapshotWindow{Name: window.Name, Layout: window.Layout, Active: window.Active}
+			panes, err := tmux.TmuxListPanes(window.Id)
+			if err != nil {
+				return snapshot, err
+			}
+			for _, pane := range panes {
+				savedPane := SnapshotPane{Path: pane.Path, Active: pane.Active}
+				if !slices.Contains(shells, pane.Command) {
+					savedPane.Command = pane.Command
+				}
+				if scrollback {
+					savedPane.Scrollback, err = tmux.TmuxCaptureHistory(pane.Id)
+					if err != nil {
+						return snapshot, err
+					}
+				}
+				savedWindow.Panes = append(savedWindow.Panes, savedPane)
+			}
+			saved.Windows = append(saved.Windows, savedWindow)
+		}
+		snapshot.Sessions = append(snapshot.Sessions, saved)
+	}
+
+	return snapshot, nil
+}
+
+func SaveSnapshot(path string, snapshot Snapshot) error {
+	return writeJSON(path, snapshot)
+}
+
+// writeJSON writes v next to the old file first, so a failed save never
+// leaves a truncated file behind.
+func writeJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Merge adds the sessions of other to the snapshot, replacing the ones that
+// were saved before under the same name.
+func (snapshot *Snapshot) Merge(other Snapshot) {
+	for _, session := range other.Sessions {
+		i := slices.IndexFunc(snapshot.Sessions, func(s SnapshotSession) bool { return s.Name == session.Name })
+		if i < 0 {
+			snapshot.Sessions = append(snapshot.Sessions, session)
+		} else {
+			snapshot.Sessions[i] = session
+		}
+	}
+	snapshot.Version = other.Version
+	snapshot.Created = other.Created
+}
+
+func LoadSnapshot(path string) (Snapshot, error) {
+	var snapshot Snapshot
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return snapshot, err
+	}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return snapshot, fmt.Errorf("%s: %w", path, err)
+	}
+	if snapshot.Version > SnapshotVersion {
+		return snapshot, fmt.Errorf("%s: snapshot version %d is newer than this tsm understands", path, snapshot.Version)
+	}
+	return snapshot, nil
+}
+
+// RestoreSnapshot brings back the named sessions, or all of them when no
+// names are given, and returns the ones it restored. Sessions that are
+// already running are left alone.
+func RestoreSnapshot(tmux Tmuxer, snapshot Snapshot, names ...string) ([]string, error) {
+	running, err := tmux.TmuxListSessions()
+	if err != nil {
+		return nil, err
+	}
+	var restored []string
+	for _, session := range snapshot.Sessions {
+		if len(names) > 0 && !slices.Contains(names, session.Name) {
+			continue
+		}
+		if slices.ContainsFunc(running, func(s Session) bool { return s.Name == session.Name }) {
+			continue
+		}
+		if err := RestoreSession(tmux, session); err != nil {
+			return restored, fmt.Errorf("restoring %s: %w", session.Name, err)
+		}
+		restored = append(restored, session.Name)
+	}
+	return restored, nil
+}
+
+// RestoreSession rebuilds a single saved session the same way a layout is
+// built, then selects the windows and panes that were active.
+func RestoreSession(tmux Tmuxer, session SnapshotSession) error {
+	layout := snapshotLayout(session)
+	for i, window := range session.Windows {
+		for j, pane := range window.Panes {
+			if len(pane.Scrollback) == 0 {
+				continue
+			}
+			replay, err := replayScrollback(pane.Scrollback)
+			if err != nil {
+				return err
+			}
+			layout.Windows[i].Panes[j].Command = strings.Join(slices.DeleteFunc([]string{replay, pane.Command}, func(s string) bool { return len(s) == 0 }), "; ")
+		}
+	}
+	if err := ApplyLayout(tmux, layout); err != nil {
+		return err
+	}
+	if len(session.Tags) > 0 {
+		if err := tmux.TmuxSetTags(session.Name, session.Tags); err != nil {
+			return err
+		}
+	}
+
+	windows, err := tmux.TmuxListWindows(session.Name)
+	if err != nil {
+		return err
+	}
+	for i, window := range session.Windows {
+		if i >= len(windows) {
+			break
+		}
+		panes, err := tmux.TmuxListPanes(windows[i].Id)
+		if err != nil {
+			return err
+		}
+		for j, pane := range window.Panes {
+			if pane.Active && j < len(panes) {
+				if err := tmux.TmuxSelectPane(panes[j].Id); err != nil {
+					return err
+				}
+			}
+		}
+		if window.Active {
+			if err := tmux.TmuxSelectWindow(windows[i].Id); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// snapshotLayout describes a saved session as a layout. The saved layout
+// string puts the panes back exactly where they were.
+func snapshotLayout(session SnapshotSession) Layout {
+	layout := Layout{Name: session.Name, Root: session.Path}
+	for _, window := range session.Windows {
+		restored := LayoutWindow{Name: window.Name, Layout: window.Layout}
+		for _, pane := range window.Panes {
+			restored.Panes = append(restored.Panes, LayoutPane{Command: pane.Command, Root: pane.Path})
+		}
+		if len(restored.Panes) > 0 {
+			restored.Root = restored.Panes[0].Root
+		}
+		if len(layout.Root) == 0 {
+			layout.Root = restored.Root
+		}
+		layout.Windows = append(layout.Windows, restored)
+	}
+	return layout
+}
+
+// replayScrollback parks the scrollback in a file and returns the command
+// that prints it into the new pane and cleans up after itself.
+func replayScrollback(scrollback string) (string, error) {
+	file, err := os.CreateTemp("", "tsm-scrollback-*")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	if _, err := file.WriteString(scrollback); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("cat %[1]s; rm -f %[1]s", shellQuote(file.Name())), nil
+}
+
+// snapshotMsg delivers the sessions of the last snapshot.
+type snapshotMsg struct {
+	sessions []SnapshotSession
+	err      error
+}
+
+func discoverSnapshot(path string) tea.Cmd {
+	if _, err := os.Stat(path); len(path) == 0 || err != nil {
+		return nil
+	}
+	return func() tea.Msg {
+		snapshot, err := LoadSnapshot(path)
+		return snapshotMsg{sessions: snapshot.Sessions, err: err}
+	}
+}
+
+func restoreSession(tmux Tmuxer, session SnapshotSession) tea.Cmd {
+	return func() tea.Msg {
+		return layoutAppliedMsg{session: session.Name, err: RestoreSession(tmux, session)}
+	}
+}
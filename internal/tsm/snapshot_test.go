@@ -0,0 +1,172 @@
+package tsm
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func snapshotTmux() *MockTmux {
+	return &MockTmux{
+		sessions: []Session{{Name: "work", Path: "/src/work"}},
+		windows: map[string][]Window{
+			"work": {
+				{Id: "@1", Name: "editor", Layout: "abcd,80x24,0,0{40x24,0,0,1,39x24,41,0,2}"},
+				{Id: "@2", Name: "shell", Active: true},
+			},
+		},
+		panes: map[string][]Pane{
+			"@1": {
+				{Id: "%1", Command: "nvim", Path: "/src/work"},
+				{Id: "%2", Command: "zsh", Path: "/src/work/cmd", Active: true},
+			},
+			"@2": {{Id: "%3", Command: "bash", Path: "/tmp", Active: true}},
+		},
+		environment: map[string]string{},
+		layouts:     map[string]string{},
+	}
+}
+
+func TestTakeSnapshot(t *testing.T) {
+	snapshot, err := TakeSnapshot(snapshotTmux(), false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if snapshot.Version != SnapshotVersion || len(snapshot.Sessions) != 1 {
+		t.Fatalf("Expected one session at version %d, got %+v", SnapshotVersion, snapshot)
+	}
+	editor := snapshot.Sessions[0].Windows[0]
+	if editor.Layout != "abcd,80x24,0,0{40x24,0,0,1,39x24,41,0,2}" {
+		t.Errorf("Expected window layout to be saved, got %s", editor.Layout)
+	}
+	if editor.Panes[0].Command != "nvim" || editor.Panes[1].Command != "" {
+		t.Errorf("Expected only non-shell commands to be saved, got %+v", editor.Panes)
+	}
+	if editor.Panes[0].Scrollback != "" {
+		t.Errorf("Expected no scrollback unless asked for, got %q", editor.Panes[0].Scrollback)
+	}
+
+	snapshot, _ = TakeSnapshot(snapshotTmux(), true)
+	if scrollback := snapshot.Sessions[0].Windows[0].Panes[0].Scrollback; scrollback != "history of %1" {
+		t.Errorf("Expected scrollback to be captured, got %q", scrollback)
+	}
+}
+
+func TestSaveAndLoadSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "snapshot.json")
+	snapshot, _ := TakeSnapshot(snapshotTmux(), false)
+	if err := SaveSnapshot(path, snapshot); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	loaded, err := LoadSnapshot(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(loaded.Sessions) != 1 || len(loaded.Sessions[0].Windows) != 2 {
+		t.Errorf("Expected the snapshot to round trip, got %+v", loaded)
+	}
+
+	snapshot.Version = SnapshotVersion + 1
+	SaveSnapshot(path, snapshot)
+	if _, err := LoadSnapshot(path); err == nil {
+		t.Errorf("Expected a newer snapshot version to be rejected")
+	}
+}
+
+func TestRestoreSnapshot(t *testing.T) {
+	snapshot, _ := TakeSnapshot(snapshotTmux(), false)
+	tmux := &MockTmux{
+		windows:     map[string][]Window{},
+		panes:       map[string][]Pane{},
+		environment: map[string]string{},
+		layouts:     map[string]string{},
+	}
+
+	restored, err := RestoreSnapshot(tmux, snapshot)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !slices.Equal(restored, []string{"work"}) {
+		t.Fatalf("Expected work to be restored, got %v", restored)
+	}
+	windows := tmux.windows["work"]
+	if names := windowNames(windows); !slices.Equal(names, []string{"editor", "shell"}) {
+		t.Fatalf("Expected windows [editor shell], got %v", names)
+	}
+	if !windows[1].Active {
+		t.Errorf("Expected shell to be the active window again")
+	}
+	editor := tmux.panes[windows[0].Id]
+	if len(editor) != 2 || editor[1].Path != "/src/work/cmd" || !editor[1].Active {
+		t.Errorf("Expected editor panes to be restored in place, got %+v", editor)
+	}
+	if tmux.layouts[windows[0].Id] != snapshot.Sessions[0].Windows[0].Layout {
+		t.Errorf("Expected the saved layout to be applied, got %s", tmux.layouts[windows[0].Id])
+	}
+	if !slices.Equal(tmux.sent_keys, []string{editor[0].Id + " nvim"}) {
+		t.Errorf("Expected nvim to be restarted, got %v", tmux.sent_keys)
+	}
+
+	restored, _ = RestoreSnapshot(tmux, snapshot)
+	if len(restored) != 0 || len(tmux.sessions) != 1 {
+		t.Errorf("Expected running sessions to be left alone, got %v", restored)
+	}
+}
+
+func TestRestoreSnapshotReplaysScrollback(t *testing.T) {
+	snapshot, _ := TakeSnapshot(snapshotTmux(), true)
+	tmux := &MockTmux{
+		windows:     map[string][]Window{},
+		panes:       map[string][]Pane{},
+		environment: map[string]string{},
+		layouts:     map[string]string{},
+	}
+
+	if _, err := RestoreSnapshot(tmux, snapshot, "work"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(tmux.sent_keys) != 3 {
+		t.Fatalf("Expected scrollback to be replayed in every pane, got %v", tmux.sent_keys)
+	}
+	if !strings.Contains(tmux.sent_keys[0], "cat '") || !strings.HasSuffix(tmux.sent_keys[0], "; nvim") {
+		t.Errorf("Expected scrollback to be printed before nvim starts, got %s", tmux.sent_keys[0])
+	}
+}
+
+func TestSnapshotSessionsAreListed(t *testing.T) {
+	snapshot, _ := TakeSnapshot(snapshotTmux(), false)
+	snapshot.Sessions = append(snapshot.Sessions, SnapshotSession{Name: "test_session_1"})
+	tmux := &MockTmux{
+		sessions:    testSessions("test_session_1"),
+		windows:     map[string][]Window{},
+		panes:       map[string][]Pane{},
+		environment: map[string]string{},
+		layouts:     map[string]string{},
+	}
+	test_model := InitialSessionModel(tmux, Config{})
+
+	updModel, _ := test_model.Update(snapshotMsg{sessions: snapshot.Sessions})
+	test_model = updModel.(model)
+	if names := choiceNames(test_model.choices); !slices.Equal(names, []string{"test_session_1", "work"}) {
+		t.Fatalf("Expected only the saved session that is not running to be listed, got %v", names)
+	}
+	if test_model.choices[1].kind != SNAPSHOT_CHOICE {
+		t.Errorf("Expected work to be a snapshot choice")
+	}
+
+	updModel, _ = test_model.Update(tea.KeyMsg(tea.Key{Type: tea.KeyRunes, Runes: []rune{'j'}}))
+	updModel, cmd := updModel.(model).Update(tea.KeyMsg(tea.Key{Type: tea.KeyEnter}))
+	if cmd == nil {
+		t.Fatalf("Expected enter to restore the saved session")
+	}
+	updModel, cmd = updModel.(model).Update(cmd())
+	if tmux.active_session != "work" {
+		t.Errorf("Expected to switch to work, got %s", tmux.active_session)
+	}
+	if cmd == nil {
+		t.Errorf("Expected to quit after restoring")
+	}
+}
@@ -0,0 +1,177 @@
+package tsm
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// TagsOption is the session user option tags are kept in, so they last as
+// long as the session and other tools can read them.
+const TagsOption = "@tsm_tags"
+
+// ErrNoTaggedSessions is returned when no session has the tags asked for.
+var ErrNoTaggedSessions = errors.New("no sessions tagged")
+
+// tagPrefix marks a tag in the filter, as in "tag:work api".
+const tagPrefix = "tag:"
+
+// ParseTags splits tags separated by commas or spaces, sorted and without
+// repeats.
+func ParseTags(value string) []string {
+	tags := strings.FieldsFunc(value, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+	if len(tags) == 0 {
+		return nil
+	}
+	slices.Sort(tags)
+	return slices.Compact(tags)
+}
+
+// HasTags reports whether the session has every one of tags.
+func HasTags(session Session, tags []string) bool {
+	for _, tag := range tags {
+		if !slices.Contains(session.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// TaggedSessions keeps the sessions that have every one of tags.
+func TaggedSessions(sessions []Session, tags []string) []Session {
+	var tagged []Session
+	for _, session := range sessions {
+		if HasTags(session, tags) {
+			tagged = append(tagged, session)
+		}
+	}
+	return tagged
+}
+
+// parseTagQuery takes the tag: terms out of a filter query and returns them
+// along with what is left to match fuzzily.
+func parseTagQuery(query string) ([]string, string) {
+	var tags, rest []string
+	for _, term := range strings.Fields(query) {
+		if tag, ok := strings.CutPrefix(term, tagPrefix); ok {
+			if len(tag) > 0 {
+				tags = append(tags, tag)
+			}
+			continue
+		}
+		rest = append(rest, term)
+	}
+	return tags, strings.Join(rest, " ")
+}
+
+// groupByTag puts each tagged session under a header for every one of its
+// tags, after the pins and the sessions without tags. The current and
+// previous sessions of frecency stay on top instead, so going back is still
+// one key away. The sessions of a collapsed tag are left out.
+func (m model) groupByTag(all []choice) []choice {
+	var top, rest []choice
+	groups := map[string][]choice{}
+	lead := m.frecencyLead()
+	for _, c := range all {
+		switch {
+		case c.kind == SESSION_CHOICE && c.slot == 0 && len(c.session.Tags) > 0:
+			if slices.Contains(lead, c.session.Name) {
+				top = append(top, c)
+				continue
+			}
+			c.grouped = true
+			for _, tag := range c.session.Tags {
+				groups[tag] = append(groups[tag], c)
+			}
+		case c.kind == SESSION_CHOICE || c.slot > 0:
+			top = append(top, c)
+		default:
+			rest = append(rest, c)
+		}
+	}
+	for _, tag := range sortedKeys(groups) {
+		top = append(top, choice{kind: TAG_CHOICE, tag: tag, members: len(groups[tag]), collapsed: m.collapsed[tag]})
+		if !m.collapsed[tag] {
+			top = append(top, groups[tag]...)
+		}
+	}
+	return append(top, rest...)
+}
+
+// tagMembers are the names of the sessions with tag.
+func (m model) tagMembers(tag string) []string {
+	var names []string
+	for _, session := range TaggedSessions(m.sessions, []string{tag}) {
+		names = append(names, session.Name)
+	}
+	return names
+}
+
+// groupAt is the index of the header of the group the i-th choice is in, or
+// -1 when it is not in one.
+func (m model) groupAt(i int) int {
+	for ; i >= 0; i-- {
+		if m.choices[i].kind == TAG_CHOICE {
+			return i
+		}
+		if !m.choices[i].grouped {
+			return -1
+		}
+	}
+	return -1
+}
+
+// toggleCollapse folds or unfolds the group under the cursor and leaves the
+// cursor on its header.
+func (m *model) toggleCollapse() {
+	if m.cursor >= len(m.choices) {
+		return
+	}
+	header := m.groupAt(m.cursor)
+	if header < 0 {
+		return
+	}
+	tag := m.choices[header].tag
+	m.collapsed[tag] = !m.collapsed[tag]
+	m.applyFilter(m.query())
+	m.cursor = max(slices.IndexFunc(m.choices, func(c choice) bool { return c.kind == TAG_CHOICE && c.tag == tag }), 0)
+}
+
+// startTagging opens the tags input for the targets, filled with the tags
+// they all share.
+func (m *model) startTagging() {
+	targets := m.targets()
+	if len(targets) == 0 {
+		return
+	}
+	var shared []string
+	for i, name := range targets {
+		j := slices.IndexFunc(m.sessions, func(s Session) bool { return s.Name == name })
+		if j < 0 {
+			continue
+		}
+		if i == 0 {
+			shared = slices.Clone(m.sessions[j].Tags)
+			continue
+		}
+		shared = slices.DeleteFunc(shared, func(tag string) bool { return !slices.Contains(m.sessions[j].Tags, tag) })
+	}
+	m.state = TAG_STATE
+	m.focused = TAGS_INPUT
+	m.inputs[m.focused].SetValue(strings.Join(shared, ", "))
+	m.inputs[m.focused].CursorEnd()
+}
+
+// setTags gives every target the tags typed in, stopping at the first
+// session that cannot be tagged.
+func (m *model) setTags(value string) error {
+	tags := ParseTags(value)
+	for _, name := range m.targets() {
+		if err := m.tmux.TmuxSetTags(name, tags); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
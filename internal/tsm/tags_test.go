@@ -0,0 +1,181 @@
+package tsm
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func newTestTagModel() model {
+	sessions := testSessions("main", "api", "web", "notes")
+	sessions[1].Tags = []string{"go", "work"}
+	sessions[2].Tags = []string{"work"}
+	sessions[3].Tags = []string{"personal"}
+	return InitialSessionModel(&MockTmux{sessions: sessions}, Config{})
+}
+
+func TestParseTags(t *testing.T) {
+	if tags := ParseTags("work, api  go,work"); !slices.Equal(tags, []string{"api", "go", "work"}) {
+		t.Errorf("Expected sorted tags without repeats, got %v", tags)
+	}
+	if tags := ParseTags(" , "); tags != nil {
+		t.Errorf("Expected no tags, got %v", tags)
+	}
+	tags, rest := parseTagQuery("tag:work api tag: tag:go")
+	if !slices.Equal(tags, []string{"work", "go"}) || rest != "api" {
+		t.Errorf("Expected the tags taken out of the query, got %v and %q", tags, rest)
+	}
+}
+
+func TestSessionsAreGroupedByTag(t *testing.T) {
+	m := newTestTagModel()
+	expected := []string{"main", "tag:go", "api", "tag:personal", "notes", "tag:work", "api", "web"}
+	if names := choiceNames(m.choices); !slices.Equal(names, expected) {
+		t.Errorf("Expected %v, got %v", expected, names)
+	}
+}
+
+func TestGroupingKeepsThePreviousSessionSecond(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	for _, session := range []string{"web", "main"} {
+		if err := RecordSwitch(path, "", session); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sessions := testSessions("main", "api", "web", "notes", "docs")
+	sessions[0].Tags = []string{"work"}
+	sessions[2].Tags = []string{"work"}
+	sessions[4].Tags = []string{"work"}
+	tmux := &MockTmux{sessions: sessions, active_session: "main"}
+	m := InitialSessionModel(tmux, Config{SortOrder: SORT_FRECENCY, HistoryPath: path})
+
+	// The current and previous sessions are taken out of their group.
+	expected := []string{"main", "web", "api", "notes", "tag:work", "docs"}
+	names := choiceNames(m.choices)
+	if !slices.Equal(names, expected) {
+		t.Errorf("Expected %v, got %v", expected, names)
+	}
+	for _, name := range names {
+		if count := len(slices.DeleteFunc(slices.Clone(names), func(n string) bool { return n != name })); count != 1 {
+			t.Errorf("Expected %s once, got it %d times in %v", name, count, names)
+		}
+	}
+	if header := m.choices[4]; header.members != 1 {
+		t.Errorf("Expected the work group to count docs alone, got %d", header.members)
+	}
+	if m.cursor != 1 {
+		t.Errorf("Expected the cursor on the previous session, got %d", m.cursor)
+	}
+	sendKeys(m, "enter")
+	if tmux.active_session != "web" {
+		t.Errorf("Expected enter to go back to web, got %s", tmux.active_session)
+	}
+}
+
+func TestPreviousSessionFollowsTheCurrentOneAfterPinsAndGroups(t *testing.T) {
+	dir := t.TempDir()
+	history := filepath.Join(dir, "history.json")
+	for _, session := range []string{"web", "main"} {
+		if err := RecordSwitch(history, "", session); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var pins Pins
+	pins.Pin(1, "notes", "")
+	pins.Pin(2, "web", "")
+	if err := SavePins(filepath.Join(dir, "pins.json"), pins); err != nil {
+		t.Fatal(err)
+	}
+	sessions := testSessions("main", "api", "web", "notes", "docs")
+	sessions[0].Tags = []string{"work"}
+	sessions[4].Tags = []string{"work"}
+	tmux := &MockTmux{sessions: sessions, active_session: "main"}
+	m := InitialSessionModel(tmux, Config{SortOrder: SORT_FRECENCY, HistoryPath: history, PinsPath: filepath.Join(dir, "pins.json")})
+
+	expected := []string{"notes", "main", "web", "api", "tag:work", "docs"}
+	if names := choiceNames(m.choices); !slices.Equal(names, expected) {
+		t.Errorf("Expected %v, got %v", expected, names)
+	}
+	if m.cursor != 2 {
+		t.Errorf("Expected the cursor on the previous session, got %d", m.cursor)
+	}
+	sendKeys(m, "enter")
+	if tmux.active_session != "web" {
+		t.Errorf("Expected enter to go back to web, got %s", tmux.active_session)
+	}
+}
+
+func TestTagFilter(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{"tag:work", []string{"api", "web"}},
+		{"tag:work tag:go", []string{"api"}},
+		{"tag:work w", []string{"web"}},
+		{"tag:nothing", []string{}},
+	}
+	for _, test := range tests {
+		updModel, _ := sendKeys(newTestTagModel(), "/", test.query)
+		if names := choiceNames(updModel.(model).choices); !slices.Equal(names, test.expected) {
+			t.Errorf("Expected %q to show %v, got %v", test.query, test.expected, names)
+		}
+	}
+}
+
+func TestCollapseTag(t *testing.T) {
+	updModel, _ := sendKeys(newTestTagModel(), "j", "enter")
+	test_model := updModel.(model)
+	expected := []string{"main", "tag:go", "tag:personal", "notes", "tag:work", "api", "web"}
+	if names := choiceNames(test_model.choices); !slices.Equal(names, expected) {
+		t.Errorf("Expected enter on a header to fold it, got %v", names)
+	}
+
+	// z folds the group of the session under the cursor and moves to its
+	// header.
+	updModel, _ = sendKeys(test_model, "j", "j", "j", "j", "z")
+	test_model = updModel.(model)
+	expected = []string{"main", "tag:go", "tag:personal", "notes", "tag:work"}
+	if names := choiceNames(test_model.choices); !slices.Equal(names, expected) {
+		t.Errorf("Expected z to fold work, got %v", names)
+	}
+	if name := test_model.choices[test_model.cursor].name(); name != "tag:work" {
+		t.Errorf("Expected the cursor on the work header, got %s", name)
+	}
+}
+
+func TestBulkActionsOnTag(t *testing.T) {
+	// The work header is the sixth row.
+	updModel, _ := sendKeys(newTestTagModel(), "j", "j", "j", "j", "j", " ")
+	// go only has api, so it is all marked too.
+	expected := []string{"tag:go", "api", "tag:work", "api", "web"}
+	if names := markedNames(updModel.(model)); !slices.Equal(names, expected) {
+		t.Errorf("Expected space on a header to mark its group, got %v", names)
+	}
+
+	updModel, _ = sendKeys(newTestTagModel(), "j", "j", "j", "j", "j", "d", "y")
+	test_model := updModel.(model)
+	if names := sessionNames(test_model.sessions); !slices.Equal(names, []string{"main", "notes"}) {
+		t.Errorf("Expected kill on a header to kill its group, got %v", names)
+	}
+}
+
+func TestTagSessions(t *testing.T) {
+	test_model := newTestTagModel()
+	tmux := test_model.tmux.(*MockTmux)
+
+	updModel, _ := sendKeys(test_model, "t", "dev, ops", "enter")
+	if tags := tmux.sessions[0].Tags; !slices.Equal(tags, []string{"dev", "ops"}) {
+		t.Errorf("Expected main to be tagged dev and ops, got %v", tags)
+	}
+	if names := choiceNames(updModel.(model).choices); !slices.Contains(names, "tag:dev") {
+		t.Errorf("Expected a dev group, got %v", names)
+	}
+
+	// Marked sessions start from the tags they share.
+	test_model = newTestTagModel()
+	updModel, _ = sendKeys(test_model, "j", "j", " ", "j", "j", "j", "j", " ", "t")
+	if value := updModel.(model).inputs[TAGS_INPUT].Value(); value != "work" {
+		t.Errorf("Expected the shared tag to be filled in, got %q", value)
+	}
+}
@@ -0,0 +1,177 @@
+package tsm
+
+import (
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/op/redlog/pkg/catppuccin"
+)
+
+// DefaultListWidth is how wide the session list is unless configured.
+const DefaultListWidth = 40
+
+const (
+	// AUTO_THEME picks a light or dark flavour after the terminal background.
+	AUTO_THEME = "auto"
+	// MONO_THEME draws without colors, it is also used whenever NO_COLOR is
+	// set.
+	MONO_THEME = "mono"
+)
+
+// Theme is the palette tsm draws with.
+type Theme struct {
+	Background lipgloss.TerminalColor
+	Selected   lipgloss.TerminalColor
+	Help       lipgloss.TerminalColor
+	Dim        lipgloss.TerminalColor
+	Mark       lipgloss.TerminalColor
+	Match      lipgloss.TerminalColor
+	Error      lipgloss.TerminalColor
+	Border     lipgloss.TerminalColor
+	// Monochrome themes tell things apart with bold, faint and reversed text
+	// instead of colors.
+	Monochrome bool
+}
+
+func flavourTheme(flavour catppuccin.Flavour) Theme {
+	return Theme{
+		Background: flavour.Base(),
+		Selected:   flavour.Mauve(),
+		Help:       flavour.Green(),
+		Dim:        flavour.Overlay1(),
+		Mark:       flavour.Yellow(),
+		Match:      flavour.Peach(),
+		Error:      flavour.Red(),
+		Border:     lipgloss.Color("63"),
+	}
+}
+
+// themes are the built in themes a config can pick by name.
+var themes = map[string]Theme{
+	"latte":     flavourTheme(catppuccin.Latte),
+	"frappe":    flavourTheme(catppuccin.Frappe),
+	"macchiato": flavourTheme(catppuccin.Macchiato),
+	"mocha":     flavourTheme(catppuccin.Mocha),
+	MONO_THEME: {
+		Background: lipgloss.NoColor{},
+		Selected:   lipgloss.NoColor{},
+		Help:       lipgloss.NoColor{},
+		Dim:        lipgloss.NoColor{},
+		Mark:       lipgloss.NoColor{},
+		Match:      lipgloss.NoColor{},
+		Error:      lipgloss.NoColor{},
+		Border:     lipgloss.NoColor{},
+		Monochrome: true,
+	},
+}
+
+// themeColors name the colors of a Theme for user themes in the config file.
+var themeColors = map[string]func(*Theme) *lipgloss.TerminalColor{
+	"background": func(t *Theme) *lipgloss.TerminalColor { return &t.Background },
+	"selected":   func(t *Theme) *lipgloss.TerminalColor { return &t.Selected },
+	"help":       func(t *Theme) *lipgloss.TerminalColor { return &t.Help },
+	"dim":        func(t *Theme) *lipgloss.TerminalColor { return &t.Dim },
+	"mark":       func(t *Theme) *lipgloss.TerminalColor { return &t.Mark },
+	"match":      func(t *Theme) *lipgloss.TerminalColor { return &t.Match },
+	"error":      func(t *Theme) *lipgloss.TerminalColor { return &t.Error },
+	"border":     func(t *Theme) *lipgloss.TerminalColor { return &t.Border },
+}
+
+// userTheme builds a theme out of the colors set in the config file, taking
+// the rest from the default flavour.
+func userTheme(colors map[string]string) Theme {
+	theme := themes["macchiato"]
+	for name, color := range colors {
+		if field, ok := themeColors[name]; ok {
+			*field(&theme) = lipgloss.Color(color)
+		}
+	}
+	return theme
+}
+
+// resolveTheme finds the theme the config asks for. NO_COLOR wins over
+// anything configured, see https://no-color.org.
+func resolveTheme(config Config) Theme {
+	if len(os.Getenv("NO_COLOR")) > 0 {
+		return themes[MONO_THEME]
+	}
+	if theme, ok := config.Themes[config.Theme]; ok {
+		return theme
+	}
+	if theme, ok := themes[config.Theme]; ok {
+		return theme
+	}
+	if lipgloss.HasDarkBackground() {
+		return themes["macchiato"]
+	}
+	return themes["latte"]
+}
+
+// Styles are what the models render with, built from a theme and handed
+// down to every model.
+type Styles struct {
+	root     lipgloss.Style
+	help     lipgloss.Style
+	header   lipgloss.Style
+	selected lipgloss.Style
+	preview  lipgloss.Style
+	dim      lipgloss.Style
+	mark     lipgloss.Style
+	match    lipgloss.Style
+	list     lipgloss.Style
+	error    lipgloss.Style
+	warning  lipgloss.Style
+	form     lipgloss.Style
+	label    lipgloss.Style
+}
+
+// NewStyles builds the styles of theme with the list width wide. The rest of
+// the view is sized after the list.
+func NewStyles(theme Theme, width int) Styles {
+	if width <= 0 {
+		width = DefaultListWidth
+	}
+	base := lipgloss.NewStyle().Background(theme.Background)
+	s := Styles{
+		root:     base.Width(width + 10).Align(lipgloss.Center),
+		help:     base.Foreground(theme.Help).Align(lipgloss.Left).Italic(true).Faint(true),
+		header:   base.Bold(true).PaddingTop(1).PaddingBottom(1).Width(width).Align(lipgloss.Center),
+		selected: base.Foreground(theme.Selected),
+		preview:  lipgloss.NewStyle().BorderStyle(lipgloss.RoundedBorder()).BorderForeground(theme.Border),
+		dim:      base.Foreground(theme.Dim),
+		mark:     base.Foreground(theme.Mark),
+		match:    base.Foreground(theme.Match).Bold(true),
+		list:     base.Width(width).Align(lipgloss.Left).BorderStyle(lipgloss.RoundedBorder()).BorderForeground(theme.Border),
+		error:    base.Foreground(theme.Error).Width(width).Align(lipgloss.Left),
+		warning:  base.Foreground(theme.Mark),
+		form:     base.Width(width).Align(lipgloss.Left),
+		label:    base.Width(10),
+	}
+	if theme.Monochrome {
+		s.selected = s.selected.Reverse(true)
+		s.dim = s.dim.Faint(true)
+		s.mark = s.mark.Bold(true)
+		s.match = s.match.Underline(true)
+		s.error = s.error.Bold(true)
+		s.warning = s.warning.Bold(true)
+	}
+	return s
+}
+
+// compact tightens the styles for small popups: the view is no wider than
+// the list and the header loses its padding.
+func (s Styles) compact() Styles {
+	s.root = s.root.Width(s.list.GetWidth() + 2)
+	s.header = s.header.UnsetPaddingTop().UnsetPaddingBottom()
+	return s
+}
+
+// configStyles are the styles of the theme, list width and layout of the
+// config.
+func configStyles(config Config) Styles {
+	s := NewStyles(resolveTheme(config), config.ListWidth)
+	if config.Compact {
+		s = s.compact()
+	}
+	return s
+}
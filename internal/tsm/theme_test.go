@@ -0,0 +1,115 @@
+package tsm
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestResolveTheme(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	mine := userTheme(map[string]string{"selected": "#ff0000"})
+	config := Config{Theme: "mine", Themes: map[string]Theme{"mine": mine}}
+
+	theme := resolveTheme(config)
+	if theme.Selected != lipgloss.Color("#ff0000") {
+		t.Errorf("Expected the user theme, got %v", theme.Selected)
+	}
+	if theme.Background != themes["macchiato"].Background {
+		t.Errorf("Expected colors left out to come from the default flavour, got %v", theme.Background)
+	}
+	if theme := resolveTheme(Config{Theme: "latte"}); theme != themes["latte"] {
+		t.Errorf("Expected the latte flavour, got %v", theme)
+	}
+}
+
+func TestNoColorIsMonochrome(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	theme := resolveTheme(Config{Theme: "mocha"})
+	if !theme.Monochrome {
+		t.Fatalf("Expected NO_COLOR to win over the configured theme")
+	}
+	if styles := NewStyles(theme, 0); !styles.selected.GetReverse() {
+		t.Errorf("Expected the selection to stand out without colors")
+	}
+}
+
+func TestLoadConfigFileThemes(t *testing.T) {
+	path := writeConfig(t, `theme = "gruvbox"
+
+[themes.gruvbox]
+selected = "#d3869b"
+border = "63"
+`)
+	config := DefaultConfig()
+	if err := LoadConfigFile(path, &config); err != nil {
+		t.Fatal(err)
+	}
+	if config.Theme != "gruvbox" || config.Themes["gruvbox"].Selected != lipgloss.Color("#d3869b") {
+		t.Errorf("Expected the gruvbox theme, got %s with %v", config.Theme, config.Themes)
+	}
+}
+
+func TestLoadConfigFileBadThemes(t *testing.T) {
+	path := writeConfig(t, `theme = "nord"
+
+[themes.mocha]
+selected = "#fff"
+
+[themes.mine]
+selected = "red"
+glow = "#ffffff"
+`)
+	var configErr *ConfigError
+	if err := LoadConfigFile(path, &Config{}); !errors.As(err, &configErr) {
+		t.Fatalf("Expected a ConfigError, got %v", err)
+	}
+	expected := []int{1, 3, 7, 8}
+	if len(configErr.Problems) != len(expected) {
+		t.Fatalf("Expected %d problems, got %v", len(expected), configErr)
+	}
+	for i, problem := range configErr.Problems {
+		if problem.Line != expected[i] {
+			t.Errorf("Expected a problem on line %d, got %s on line %d", expected[i], problem.Key, problem.Line)
+		}
+	}
+}
+
+func TestValidColor(t *testing.T) {
+	for color, expected := range map[string]bool{
+		"#ff00aa": true,
+		"#f0a":    true,
+		"63":      true,
+		"256":     false,
+		"#ff00a":  false,
+		"#gggggg": false,
+		"red":     false,
+	} {
+		if validColor(color) != expected {
+			t.Errorf("Expected %q to be valid: %v", color, expected)
+		}
+	}
+}
+
+func TestHelpFollowsTheTheme(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	theme := userTheme(map[string]string{"help": "#00ff00", "dim": "#808080"})
+	config := Config{Theme: "mine", Themes: map[string]Theme{"mine": theme}}
+	styles := configStyles(config)
+	tmux := &MockTmux{}
+	for name, helpStyles := range map[string]help.Styles{
+		"sessions": InitialSessionModel(tmux, config).help.Styles,
+		"windows":  InitialWindowModel(tmux, Session{}, styles).help.Styles,
+		"panes":    InitialPaneModel(tmux, Session{}, Window{}, styles).help.Styles,
+		"servers":  InitialServerModel(tmux, styles).help.Styles,
+	} {
+		if color := helpStyles.ShortKey.GetForeground(); color != theme.Help {
+			t.Errorf("%s: expected the keys in the help color, got %v", name, color)
+		}
+		if color := helpStyles.FullDesc.GetForeground(); color != theme.Dim {
+			t.Errorf("%s: expected the descriptions in the dim color, got %v", name, color)
+		}
+	}
+}
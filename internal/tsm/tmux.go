@@ -1,81 +1,739 @@
 package tsm
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 )
 
+// fieldSeparator splits the fields of a -F formatted tmux line. It has to be
+// something that will never show up in a session name or a path.
+const fieldSeparator = "<|tsm|>"
+
+var paneFormat = strings.Join([]string{
+	"#{pane_id}",
+	"#{pane_index}",
+	"#{pane_current_command}",
+	"#{pane_pid}",
+	"#{pane_current_path}",
+	"#{pane_width}",
+	"#{pane_height}",
+	"#{pane_active}",
+}, fieldSeparator)
+
+var sessionFormat = strings.Join([]string{
+	"#{session_id}",
+	"#{session_name}",
+	"#{session_windows}",
+	"#{session_attached}",
+	"#{session_created}",
+	"#{session_activity}",
+	"#{session_group}",
+	"#{session_path}",
+	"#{" + TagsOption + "}",
+}, fieldSeparator)
+
+var windowFormat = strings.Join([]string{
+	"#{window_id}",
+	"#{window_index}",
+	"#{window_name}",
+	"#{window_active}",
+	"#{window_panes}",
+	"#{window_layout}",
+}, fieldSeparator)
+
+type Session struct {
+	Id           string
+	Name         string
+	Windows      int
+	Attached     int
+	Created      time.Time
+	LastActivity time.Time
+	Group        string
+	Path         string
+	Tags         []string
+}
+
+type Window struct {
+	Id     string
+	Index  int
+	Name   string
+	Active bool
+	Panes  int
+	Layout string
+}
+
+// Server is a tmux server, found by its socket.
+type Server struct {
+	Name     string
+	Path     string
+	Alive    bool
+	Sessions int
+	Current  bool
+}
+
+type Pane struct {
+	Id      string
+	Index   int
+	Command string
+	Pid     int
+	Path    string
+	Width   int
+	Height  int
+	Active  bool
+}
+
+var (
+	ErrNoServer         = errors.New("no tmux server running")
+	ErrSessionNotFound  = errors.New("session not found")
+	ErrDuplicateSession = errors.New("duplicate session")
+	ErrPermissionDenied = errors.New("permission denied on tmux socket")
+)
+
+// TmuxError is returned whenever a tmux invocation fails. Err is one of the
+// sentinel errors above when the cause could be recognised from stderr.
+type TmuxError struct {
+	Args   []string
+	Stderr string
+	Err    error
+}
+
+func (e *TmuxError) Error() string {
+	if len(e.Stderr) > 0 {
+		return fmt.Sprintf("tmux %s: %s", e.Args[0], e.Stderr)
+	}
+	return fmt.Sprintf("tmux %s: %v", e.Args[0], e.Err)
+}
+
+func (e *TmuxError) Unwrap() error {
+	return e.Err
+}
+
+func parseTmuxError(args []string, stderr string, err error) error {
+	stderr = strings.TrimSpace(stderr)
+	switch {
+	case strings.Contains(stderr, "no server running"),
+		strings.Contains(stderr, "error connecting to") && strings.Contains(stderr, "No such file or directory"):
+		err = ErrNoServer
+	case strings.Contains(stderr, "Permission denied"):
+		err = ErrPermissionDenied
+	case strings.Contains(stderr, "can't find session"),
+		strings.Contains(stderr, "no such session"),
+		strings.Contains(stderr, "session not found"):
+		err = ErrSessionNotFound
+	case strings.Contains(stderr, "duplicate session"):
+		err = ErrDuplicateSession
+	}
+
+	return &TmuxError{Args: args, Stderr: stderr, Err: err}
+}
+
 type Tmuxer interface {
-	TmuxListSessions() []string
+	TmuxListSessions() ([]Session, error)
 	TmuxKillSession(session string) error
+	TmuxDetachClients(session string) error
 	TmuxSwitchSession(session string) error
-	TmuxCreateSession(session string) error
+	TmuxCurrentSession() (string, error)
+	TmuxCreateSession(opts CreateSessionOptions) error
 	TmuxRenameSession(oldSession string, session string) error
+	TmuxSetTags(session string, tags []string) error
+	TmuxListWindows(session string) ([]Window, error)
+	TmuxCreateWindow(session string, name string, dir string) (string, error)
+	TmuxRenameWindow(window string, name string) error
+	TmuxKillWindow(window string) error
+	TmuxSelectWindow(window string) error
+	TmuxSwapWindow(src string, dst string) error
+	TmuxMoveWindow(window string, session string, index int) error
+	TmuxListPanes(window string) ([]Pane, error)
+	TmuxSelectPane(pane string) error
+	TmuxKillPane(pane string) error
+	TmuxSplitPane(pane string, horizontal bool, dir string) (string, error)
+	TmuxBreakPane(pane string) error
+	TmuxJoinPane(src string, dst string) error
+	TmuxZoomPane(pane string) error
+	TmuxSwapPane(src string, dst string) error
+	TmuxCapturePane(target string) (string, error)
+	TmuxCaptureHistory(pane string) (string, error)
+	TmuxSetEnvironment(session string, name string, value string) error
+	TmuxSendKeys(pane string, command string) error
+	TmuxSelectLayout(window string, layout string) error
+	TmuxRespawnPane(pane string, dir string) error
+	TmuxListServers() ([]Server, error)
+	TmuxOnServer(server Server) Tmuxer
 }
 
 type Tmux struct {
-	sessions []string
+	sessions []Session
+	// socket_name and socket_path pick the server like tmux -L and -S do,
+	// the default one is used when both are empty.
+	socket_name string
+	socket_path string
+	// attach is shared by every Tmux derived from this one, see Attach.
+	attach *pendingAttach
+	// history_path is where switches are recorded, nowhere when empty.
+	history_path string
+	// client is the tty of the client to switch, the one tmux takes for
+	// current when empty.
+	client string
+}
+
+// pendingAttach is the session picked while running outside of tmux, where
+// there is no client to switch and one has to be attached instead.
+type pendingAttach struct {
+	server  []string
+	session string
+}
+
+// NewTmux talks to the server on the named socket in the tmux directory, or
+// to the socket at path, or to the default server when both are empty.
+func NewTmux(socketName string, socketPath string) *Tmux {
+	return &Tmux{socket_name: socketName, socket_path: socketPath, attach: &pendingAttach{}}
+}
+
+// RecordSwitches makes every switch made through this Tmux, and the ones
+// derived from it, count in the history at path.
+func (tmux *Tmux) RecordSwitches(path string) {
+	tmux.history_path = path
+}
+
+// UseClient makes switches move the client on tty rather than the current
+// one, which a popup does not have.
+func (tmux *Tmux) UseClient(tty string) {
+	tmux.client = tty
+}
+
+// onClient starts a client command, with flag picking the client when one
+// was given.
+func (tmux *Tmux) onClient(command string, flag string) []string {
+	if len(tmux.client) == 0 {
+		return []string{command}
+	}
+	return []string{command, flag, tmux.client}
 }
 
-func (tmux *Tmux) TmuxListSessions() []string {
-	cmd := exec.Command("tmux", "list-sessions")
+// serverArgs are the flags that point tmux at this server.
+func (tmux *Tmux) serverArgs() []string {
+	switch {
+	case len(tmux.socket_path) > 0:
+		return []string{"-S", tmux.socket_path}
+	case len(tmux.socket_name) > 0:
+		return []string{"-L", tmux.socket_name}
+	}
+	return nil
+}
+
+// SocketPath is where the server this Tmux talks to listens.
+func (tmux *Tmux) SocketPath() string {
+	switch {
+	case len(tmux.socket_path) > 0:
+		return tmux.socket_path
+	case len(tmux.socket_name) > 0:
+		return filepath.Join(socketDir(), tmux.socket_name)
+	case insideTmux():
+		return currentSocket()
+	}
+	return filepath.Join(socketDir(), "default")
+}
+
+func (tmux *Tmux) run(args ...string) (string, error) {
+	cmd := exec.Command("tmux", append(tmux.serverArgs(), args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
 	out, err := cmd.Output()
 	if err != nil {
-		fmt.Printf("Error running tmux list-sessions: %v", err)
-		os.Exit(1)
+		return "", parseTmuxError(args, stderr.String(), err)
+	}
+
+	return string(out), nil
+}
+
+// TmuxListSessions returns no sessions rather than an error when there is no
+// server, creating the first session starts one.
+func (tmux *Tmux) TmuxListSessions() ([]Session, error) {
+	out, err := tmux.run("list-sessions", "-F", sessionFormat)
+	if errors.Is(err, ErrNoServer) {
+		return []Session{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := parseSessions(out)
+	if err != nil {
+		return nil, err
+	}
+	tmux.sessions = sessions
+
+	return tmux.sessions, nil
+}
+
+func parseSessions(out string) ([]Session, error) {
+	sessions := make([]Session, 0)
+	for _, line := range strings.Split(out, "\n") {
+		if len(line) == 0 {
+			continue
+		}
+		fields := strings.Split(line, fieldSeparator)
+		if len(fields) != 9 {
+			return nil, fmt.Errorf("unexpected session line %q", line)
+		}
+		windows, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("bad window count %q: %w", fields[2], err)
+		}
+		attached, err := strconv.Atoi(fields[3])
+		if err != nil {
+			return nil, fmt.Errorf("bad attached count %q: %w", fields[3], err)
+		}
+		created, err := parseTimestamp(fields[4])
+		if err != nil {
+			return nil, err
+		}
+		activity, err := parseTimestamp(fields[5])
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, Session{
+			Id:           fields[0],
+			Name:         fields[1],
+			Windows:      windows,
+			Attached:     attached,
+			Created:      created,
+			LastActivity: activity,
+			Group:        fields[6],
+			Path:         fields[7],
+			Tags:         ParseTags(fields[8]),
+		})
 	}
-	sessions := strings.Split(string(out[:]), "\n")
-	cleanedSessions := make([]string, 0)
-	for _, item := range sessions {
-		cleanedItem := strings.Split(item, ":")[0]
-		if len(cleanedItem) > 0 {
-			cleanedSessions = append(cleanedSessions, cleanedItem)
+
+	return sessions, nil
+}
+
+func parseWindows(out string) ([]Window, error) {
+	windows := make([]Window, 0)
+	for _, line := range strings.Split(out, "\n") {
+		if len(line) == 0 {
+			continue
+		}
+		fields := strings.Split(line, fieldSeparator)
+		if len(fields) != 6 {
+			return nil, fmt.Errorf("unexpected window line %q", line)
 		}
+		index, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("bad window index %q: %w", fields[1], err)
+		}
+		panes, err := strconv.Atoi(fields[4])
+		if err != nil {
+			return nil, fmt.Errorf("bad pane count %q: %w", fields[4], err)
+		}
+		windows = append(windows, Window{
+			Id:     fields[0],
+			Index:  index,
+			Name:   fields[2],
+			Active: fields[3] == "1",
+			Panes:  panes,
+			Layout: fields[5],
+		})
 	}
-	tmux.sessions = cleanedSessions
 
-	return tmux.sessions
+	return windows, nil
+}
+
+func parsePanes(out string) ([]Pane, error) {
+	panes := make([]Pane, 0)
+	for _, line := range strings.Split(out, "\n") {
+		if len(line) == 0 {
+			continue
+		}
+		fields := strings.Split(line, fieldSeparator)
+		if len(fields) != 8 {
+			return nil, fmt.Errorf("unexpected pane line %q", line)
+		}
+		numbers := make([]int, 0, 4)
+		for _, field := range []string{fields[1], fields[3], fields[5], fields[6]} {
+			number, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("bad number %q in pane line: %w", field, err)
+			}
+			numbers = append(numbers, number)
+		}
+		panes = append(panes, Pane{
+			Id:      fields[0],
+			Index:   numbers[0],
+			Command: fields[2],
+			Pid:     numbers[1],
+			Path:    fields[4],
+			Width:   numbers[2],
+			Height:  numbers[3],
+			Active:  fields[7] == "1",
+		})
+	}
+
+	return panes, nil
+}
+
+func parseTimestamp(field string) (time.Time, error) {
+	if len(field) == 0 {
+		return time.Time{}, nil
+	}
+	seconds, err := strconv.ParseInt(field, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("bad timestamp %q: %w", field, err)
+	}
+	return time.Unix(seconds, 0), nil
+}
+
+// exactSession targets session by its whole name. tmux matches a bare name
+// as a prefix or a pattern too, so "prod" would find "production".
+func exactSession(session string) string {
+	return "=" + session
 }
 
 func (tmux *Tmux) TmuxKillSession(session string) error {
-	cmd := exec.Command("tmux", "kill-session", "-t", session)
-	err := cmd.Run()
+	_, err := tmux.run("kill-session", "-t", exactSession(session))
+	return err
+}
+
+// TmuxDetachClients detaches every client attached to session.
+func (tmux *Tmux) TmuxDetachClients(session string) error {
+	_, err := tmux.run("detach-client", "-s", exactSession(session))
+	return err
+}
+
+// TmuxSwitchSession moves the client to session, see UseClient. Outside of
+// tmux the session is only checked and remembered for Attach.
+func (tmux *Tmux) TmuxSwitchSession(session string) error {
+	from, _ := tmux.TmuxCurrentSession()
+	if err := tmux.switchSession(session); err != nil {
+		return err
+	}
+	// The history only orders the list, failing to write it is no reason to
+	// report a switch that worked as failed.
+	_ = RecordSwitch(tmux.history_path, from, session)
+	return nil
+}
+
+func (tmux *Tmux) switchSession(session string) error {
+	if !insideTmux() {
+		if _, err := tmux.run("has-session", "-t", exactSession(session)); err != nil {
+			return err
+		}
+		if tmux.attach == nil {
+			tmux.attach = &pendingAttach{}
+		}
+		*tmux.attach = pendingAttach{server: tmux.serverArgs(), session: session}
+		return nil
+	}
+	if tmux.SocketPath() != currentSocket() {
+		// A client cannot be switched to another server, so it detaches and
+		// attaches again over there.
+		if _, err := tmux.run("has-session", "-t", exactSession(session)); err != nil {
+			return err
+		}
+		attach := strings.Join([]string{"tmux", "-S", shellQuote(tmux.SocketPath()), "attach-session", "-t", shellQuote(exactSession(session))}, " ")
+		_, err := (&Tmux{}).run(append(tmux.onClient("detach-client", "-t"), "-E", attach)...)
+		return err
+	}
+	_, err := tmux.run(append(tmux.onClient("switch-client", "-c"), "-t", exactSession(session))...)
+	return err
+}
+
+// TmuxCurrentSession is the session of the client tsm runs in, or nothing
+// outside of tmux and on other servers.
+func (tmux *Tmux) TmuxCurrentSession() (string, error) {
+	if !insideTmux() || tmux.SocketPath() != currentSocket() {
+		return "", nil
+	}
+	out, err := tmux.run(append(tmux.onClient("display-message", "-c"), "-p", "#{session_name}")...)
+	return strings.TrimSpace(out), err
+}
+
+// Attach replaces the process with a tmux client attached to the session
+// switched to while running outside of tmux. It is meant to be called once
+// the UI has exited and does nothing when there is no such session.
+func (tmux *Tmux) Attach() error {
+	if tmux.attach == nil || len(tmux.attach.session) == 0 {
+		return nil
+	}
+	path, err := exec.LookPath("tmux")
 	if err != nil {
-		fmt.Printf("Error killing session: %v", err)
 		return err
 	}
+	args := append([]string{"tmux"}, tmux.attach.server...)
+	args = append(args, "attach-session", "-t", tmux.attach.session)
+	return syscall.Exec(path, args, os.Environ())
+}
+
+// TmuxListServers finds the sockets in the tmux directory, plus the one this
+// Tmux talks to, and asks each of them how many sessions it has. Sockets
+// left behind by servers that have exited are listed as not alive.
+func (tmux *Tmux) TmuxListServers() ([]Server, error) {
+	current := tmux.SocketPath()
+	var paths []string
+	entries, err := os.ReadDir(socketDir())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.Type()&os.ModeSocket != 0 {
+			paths = append(paths, filepath.Join(socketDir(), entry.Name()))
+		}
+	}
+	if !slices.Contains(paths, current) {
+		paths = append(paths, current)
+	}
+
+	servers := make([]Server, len(paths))
+	for i, path := range paths {
+		servers[i] = Server{Name: path, Path: path, Current: path == current}
+		if filepath.Dir(path) == socketDir() {
+			servers[i].Name = filepath.Base(path)
+		}
+		out, err := (&Tmux{socket_path: path}).run("list-sessions", "-F", "#{session_id}")
+		if err == nil {
+			servers[i].Alive = true
+			servers[i].Sessions = strings.Count(out, "\n")
+		}
+	}
+	return servers, nil
+}
+
+// TmuxOnServer returns a Tmux for another server that attaches through this
+// one once the UI exits.
+func (tmux *Tmux) TmuxOnServer(server Server) Tmuxer {
+	if tmux.attach == nil {
+		tmux.attach = &pendingAttach{}
+	}
+	return &Tmux{socket_path: server.Path, attach: tmux.attach, history_path: tmux.history_path, client: tmux.client}
+}
 
+func insideTmux() bool {
+	return len(os.Getenv("TMUX")) > 0
+}
+
+// currentSocket is the socket of the server tsm runs inside of, the first
+// field of $TMUX.
+func currentSocket() string {
+	socket, _, _ := strings.Cut(os.Getenv("TMUX"), ",")
+	return socket
+}
+
+// socketDir is where tmux puts the sockets of servers started with -L.
+func socketDir() string {
+	dir := os.Getenv("TMUX_TMPDIR")
+	if len(dir) == 0 {
+		dir = "/tmp"
+	}
+	return filepath.Join(dir, fmt.Sprintf("tmux-%d", os.Getuid()))
+}
+
+// shellQuote wraps s in single quotes for sh.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// CreateSessionOptions describe a new session. Empty fields leave the choice
+// to tmux: the current directory and the default shell.
+type CreateSessionOptions struct {
+	Name    string
+	Dir     string
+	Command string
+}
+
+// TmuxCreateSession starts a detached session.
+func (tmux *Tmux) TmuxCreateSession(opts CreateSessionOptions) error {
+	args := []string{"new-session", "-d", "-s", opts.Name}
+	if len(opts.Dir) > 0 {
+		args = append(args, "-c", opts.Dir)
+	}
+	if len(opts.Command) > 0 {
+		args = append(args, opts.Command)
+	}
+	_, err := tmux.run(args...)
+	return err
+}
+
+// TmuxRenameSession renames a session, taking its history along.
+func (tmux *Tmux) TmuxRenameSession(oldSession string, session string) error {
+	if _, err := tmux.run("rename-session", "-t", exactSession(oldSession), session); err != nil {
+		return err
+	}
+	if history, err := LoadHistory(tmux.history_path); err == nil {
+		history.Rename(oldSession, session)
+		_ = SaveHistory(tmux.history_path, history)
+	}
 	return nil
 }
 
-func (tmux *Tmux) TmuxSwitchSession(session string) error {
-	cmd := exec.Command("tmux", "switch-client", "-t", session)
-	err := cmd.Run()
+// TmuxSetTags replaces the tags of session, no tags unset the option.
+func (tmux *Tmux) TmuxSetTags(session string, tags []string) error {
+	// set-option takes a pane, which a bare name would be matched against
+	// loosely, so the session is named exactly.
+	target := exactSession(session) + ":"
+	if len(tags) == 0 {
+		_, err := tmux.run("set-option", "-u", "-t", target, TagsOption)
+		return err
+	}
+	_, err := tmux.run("set-option", "-t", target, TagsOption, strings.Join(tags, ","))
+	return err
+}
+
+func (tmux *Tmux) TmuxListWindows(session string) ([]Window, error) {
+	out, err := tmux.run("list-windows", "-t", exactSession(session), "-F", windowFormat)
 	if err != nil {
-		fmt.Printf("Error switching session: %v", err)
+		return nil, err
 	}
+	return parseWindows(out)
+}
 
-	return nil
+// TmuxCreateWindow creates a window at the next free index of the session
+// without selecting it and returns the new window id. Empty name and dir
+// leave the choice to tmux.
+func (tmux *Tmux) TmuxCreateWindow(session string, name string, dir string) (string, error) {
+	args := []string{"new-window", "-d", "-P", "-F", "#{window_id}", "-t", exactSession(session) + ":"}
+	if len(name) > 0 {
+		args = append(args, "-n", name)
+	}
+	if len(dir) > 0 {
+		args = append(args, "-c", dir)
+	}
+	out, err := tmux.run(args...)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+func (tmux *Tmux) TmuxRenameWindow(window string, name string) error {
+	_, err := tmux.run("rename-window", "-t", window, name)
+	return err
+}
+
+func (tmux *Tmux) TmuxKillWindow(window string) error {
+	_, err := tmux.run("kill-window", "-t", window)
+	return err
+}
+
+func (tmux *Tmux) TmuxSelectWindow(window string) error {
+	_, err := tmux.run("select-window", "-t", window)
+	return err
 }
 
-func (tmux *Tmux) TmuxCreateSession(session string) error {
-	cmd := exec.Command("tmux", "new-session", "-d", "-s", session)
-	err := cmd.Run()
+func (tmux *Tmux) TmuxSwapWindow(src string, dst string) error {
+	_, err := tmux.run("swap-window", "-d", "-s", src, "-t", dst)
+	return err
+}
+
+func (tmux *Tmux) TmuxMoveWindow(window string, session string, index int) error {
+	_, err := tmux.run("move-window", "-s", window, "-t", fmt.Sprintf("%s:%d", exactSession(session), index))
+	return err
+}
+
+func (tmux *Tmux) TmuxListPanes(window string) ([]Pane, error) {
+	out, err := tmux.run("list-panes", "-t", window, "-F", paneFormat)
 	if err != nil {
-		fmt.Printf("Error creating session: %v", err)
+		return nil, err
 	}
+	return parsePanes(out)
+}
 
-	return nil
+func (tmux *Tmux) TmuxSelectPane(pane string) error {
+	_, err := tmux.run("select-pane", "-t", pane)
+	return err
 }
 
-func (tmux *Tmux) TmuxRenameSession(oldSession string, session string) error {
-	cmd := exec.Command("tmux", "rename-session", "-t", oldSession, session)
-	err := cmd.Run()
+func (tmux *Tmux) TmuxKillPane(pane string) error {
+	_, err := tmux.run("kill-pane", "-t", pane)
+	return err
+}
+
+// TmuxSplitPane splits the pane side by side when horizontal is set and top
+// to bottom otherwise, returning the id of the new pane.
+func (tmux *Tmux) TmuxSplitPane(pane string, horizontal bool, dir string) (string, error) {
+	args := []string{"split-window", "-d", "-P", "-F", "#{pane_id}", "-t", pane}
+	if horizontal {
+		args = append(args, "-h")
+	} else {
+		args = append(args, "-v")
+	}
+	if len(dir) > 0 {
+		args = append(args, "-c", dir)
+	}
+	out, err := tmux.run(args...)
 	if err != nil {
-		fmt.Printf("Error renaming session: %v", err)
+		return "", err
 	}
+	return strings.TrimSpace(out), nil
+}
 
-	return nil
+func (tmux *Tmux) TmuxBreakPane(pane string) error {
+	_, err := tmux.run("break-pane", "-d", "-s", pane)
+	return err
+}
+
+func (tmux *Tmux) TmuxJoinPane(src string, dst string) error {
+	_, err := tmux.run("join-pane", "-d", "-s", src, "-t", dst)
+	return err
+}
+
+func (tmux *Tmux) TmuxZoomPane(pane string) error {
+	_, err := tmux.run("resize-pane", "-Z", "-t", pane)
+	return err
+}
+
+func (tmux *Tmux) TmuxSwapPane(src string, dst string) error {
+	_, err := tmux.run("swap-pane", "-d", "-s", src, "-t", dst)
+	return err
+}
+
+// TmuxCapturePane returns the visible contents of the target pane with its
+// escape sequences intact. A session or window target captures its active pane.
+func (tmux *Tmux) TmuxCapturePane(target string) (string, error) {
+	return tmux.run("capture-pane", "-p", "-e", "-t", target)
+}
+
+// TmuxCaptureHistory returns everything the pane still remembers, scrollback
+// included, with wrapped lines joined back together.
+func (tmux *Tmux) TmuxCaptureHistory(pane string) (string, error) {
+	return tmux.run("capture-pane", "-p", "-e", "-J", "-S", "-", "-t", pane)
+}
+
+func (tmux *Tmux) TmuxSetEnvironment(session string, name string, value string) error {
+	_, err := tmux.run("set-environment", "-t", exactSession(session), name, value)
+	return err
+}
+
+// TmuxSendKeys types command into the pane and presses enter. The command
+// is sent literally, so one that reads like a key name such as "Enter" or
+// "C-c" is typed rather than pressed.
+func (tmux *Tmux) TmuxSendKeys(pane string, command string) error {
+	if _, err := tmux.run("send-keys", "-l", "-t", pane, "--", command); err != nil {
+		return err
+	}
+	_, err := tmux.run("send-keys", "-t", pane, "Enter")
+	return err
+}
+
+func (tmux *Tmux) TmuxSelectLayout(window string, layout string) error {
+	_, err := tmux.run("select-layout", "-t", window, layout)
+	return err
+}
+
+// TmuxRespawnPane restarts the pane's shell, optionally in another directory,
+// so that it picks up the current session environment.
+func (tmux *Tmux) TmuxRespawnPane(pane string, dir string) error {
+	args := []string{"respawn-pane", "-k", "-t", pane}
+	if len(dir) > 0 {
+		args = append(args, "-c", dir)
+	}
+	_, err := tmux.run(args...)
+	return err
 }
@@ -0,0 +1,182 @@
+package tsm
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// The block tsm owns in tmux.conf sits between these lines, so it can be
+// updated or removed without touching the rest of the file.
+const (
+	tmuxConfBegin = "# >>> tsm >>>"
+	tmuxConfEnd   = "# <<< tsm <<<"
+)
+
+// tmuxJumpTable is the key table the jump key switches to, where the digits
+// jump to the pins.
+const tmuxJumpTable = "tsm-jump"
+
+// ErrNoTmuxConfBlock is returned when there is no tsm block to remove.
+var ErrNoTmuxConfBlock = errors.New("no tsm block")
+
+// TmuxBindings are the keys of the tmux.conf block. An empty key leaves its
+// binding out.
+type TmuxBindings struct {
+	// Command is how tmux runs tsm.
+	Command string
+	// Popup, Last, Save and Jump are bound after the prefix key.
+	Popup string
+	Last  string
+	Save  string
+	// Jump is followed by the digit of a pin slot. The digits alone already
+	// select windows, so they get a key table of their own.
+	Jump string
+}
+
+func DefaultTmuxBindings() TmuxBindings {
+	return TmuxBindings{
+		Command: "tsm",
+		Popup:   "s",
+		Last:    "L",
+		Save:    "C-s",
+		Jump:    "j",
+	}
+}
+
+// DefaultTmuxConfPath is the tmux.conf tmux reads: the first of the places
+// tmux looks that exists, ~/.tmux.conf when none does.
+func DefaultTmuxConfPath() string {
+	paths := []string{expandHome("~/.tmux.conf")}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); len(dir) > 0 {
+		paths = append(paths, filepath.Join(dir, "tmux", "tmux.conf"))
+	}
+	paths = append(paths, expandHome("~/.config/tmux/tmux.conf"))
+	for _, path := range paths {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return paths[0]
+}
+
+// TmuxConfBlock is the marked block of tmux.conf lines for the bindings.
+func TmuxConfBlock(bindings TmuxBindings) string {
+	lines := []string{
+		tmuxConfBegin,
+		"# Written by tsm init tmux, run it again rather than editing this block.",
+	}
+	// The client the key was pressed in is passed on, run-shell has none.
+	client := "--client '#{client_tty}'"
+	if len(bindings.Popup) > 0 {
+		lines = append(lines, fmt.Sprintf(`bind-key %s run-shell -b "%s popup %s"`, bindings.Popup, bindings.Command, client))
+	}
+	if len(bindings.Last) > 0 {
+		// In place of switch-client -l, which knows nothing of the sessions
+		// switched to from tsm.
+		lines = append(lines, fmt.Sprintf(`bind-key %s run-shell -b "%s switch --last %s"`, bindings.Last, bindings.Command, client))
+	}
+	if len(bindings.Save) > 0 {
+		// run-shell shows whatever the command prints, errors are enough.
+		lines = append(lines, fmt.Sprintf(`bind-key %s run-shell -b "%s snapshot save >/dev/null"`, bindings.Save, bindings.Command))
+	}
+	if len(bindings.Jump) > 0 {
+		lines = append(lines, fmt.Sprintf("bind-key %s switch-client -T %s", bindings.Jump, tmuxJumpTable))
+		for slot := 1; slot <= PinSlots; slot++ {
+			lines = append(lines, fmt.Sprintf(`bind-key -T %s %d run-shell -b "%s jump %d %s"`, tmuxJumpTable, slot, bindings.Command, slot, client))
+		}
+	}
+	lines = append(lines, tmuxConfEnd)
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// cutTmuxConfBlock splits conf around the tsm block, found reports whether
+// there is one.
+func cutTmuxConfBlock(conf string) (before string, after string, found bool) {
+	begin := strings.Index(conf, tmuxConfBegin+"\n")
+	if begin < 0 {
+		return conf, "", false
+	}
+	end := strings.Index(conf[begin:], tmuxConfEnd)
+	if end < 0 {
+		return conf, "", false
+	}
+	end += begin + len(tmuxConfEnd)
+	after = strings.TrimPrefix(conf[end:], "\n")
+	return conf[:begin], after, true
+}
+
+// withTmuxConfBlock puts block in place of the tsm block of conf, or at the
+// end when there is none yet.
+func withTmuxConfBlock(conf string, block string) string {
+	before, after, found := cutTmuxConfBlock(conf)
+	if found {
+		return before + block + after
+	}
+	if len(conf) > 0 && !strings.HasSuffix(conf, "\n") {
+		conf += "\n"
+	}
+	if len(conf) > 0 {
+		conf += "\n"
+	}
+	return conf + block
+}
+
+// withoutTmuxConfBlock takes the tsm block out of conf.
+func withoutTmuxConfBlock(conf string) (string, bool) {
+	before, after, found := cutTmuxConfBlock(conf)
+	if !found {
+		return conf, false
+	}
+	if len(after) > 0 {
+		return before + after, true
+	}
+	// Drop the blank line the block was appended after.
+	conf = strings.TrimRight(before, "\n")
+	if len(conf) > 0 {
+		conf += "\n"
+	}
+	return conf, true
+}
+
+// InstallTmuxConfBlock writes block into the tmux.conf at path, replacing
+// the tsm block that is there already. The file is created when missing.
+func InstallTmuxConfBlock(path string, block string) error {
+	conf, mode, err := readTmuxConf(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(withTmuxConfBlock(conf, block)), mode)
+}
+
+// RemoveTmuxConfBlock takes the tsm block out of the tmux.conf at path.
+func RemoveTmuxConfBlock(path string) error {
+	conf, mode, err := readTmuxConf(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%s: %w", path, ErrNoTmuxConfBlock)
+	}
+	if err != nil {
+		return err
+	}
+	conf, found := withoutTmuxConfBlock(conf)
+	if !found {
+		return fmt.Errorf("%s: %w", path, ErrNoTmuxConfBlock)
+	}
+	return os.WriteFile(path, []byte(conf), mode)
+}
+
+// readTmuxConf reads the tmux.conf at path along with its permissions, so
+// writing it back keeps them.
+func readTmuxConf(path string) (string, os.FileMode, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", 0o644, err
+	}
+	data, err := os.ReadFile(path)
+	return string(data), info.Mode().Perm(), err
+}
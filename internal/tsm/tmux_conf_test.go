@@ -0,0 +1,103 @@
+package tsm
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTmuxConfBlock(t *testing.T) {
+	block := TmuxConfBlock(DefaultTmuxBindings())
+	for _, line := range []string{
+		`bind-key s run-shell -b "tsm popup --client '#{client_tty}'"`,
+		`bind-key L run-shell -b "tsm switch --last --client '#{client_tty}'"`,
+		`bind-key C-s run-shell -b "tsm snapshot save >/dev/null"`,
+		"bind-key j switch-client -T tsm-jump",
+		`bind-key -T tsm-jump 9 run-shell -b "tsm jump 9 --client '#{client_tty}'"`,
+	} {
+		if !strings.Contains(block, line+"\n") {
+			t.Errorf("Expected %q in the block, got\n%s", line, block)
+		}
+	}
+	if strings.Contains(block, "bind-key -n") {
+		t.Errorf("Expected every binding to be after the prefix, got\n%s", block)
+	}
+
+	bindings := DefaultTmuxBindings()
+	bindings.Command = "/opt/bin/tsm"
+	bindings.Popup = "C-f"
+	bindings.Jump = ""
+	block = TmuxConfBlock(bindings)
+	if !strings.Contains(block, `bind-key C-f run-shell -b "/opt/bin/tsm popup`) {
+		t.Errorf("Expected the popup on C-f through /opt/bin/tsm, got\n%s", block)
+	}
+	if strings.Contains(block, "jump") {
+		t.Errorf("Expected no jump bindings without a jump key, got\n%s", block)
+	}
+	if !strings.HasPrefix(block, tmuxConfBegin+"\n") || !strings.HasSuffix(block, tmuxConfEnd+"\n") {
+		t.Errorf("Expected the block to be marked, got\n%s", block)
+	}
+}
+
+func TestWithTmuxConfBlock(t *testing.T) {
+	old := TmuxConfBlock(TmuxBindings{Command: "tsm", Popup: "s"})
+	block := TmuxConfBlock(TmuxBindings{Command: "tsm", Popup: "f"})
+	cases := []struct {
+		name     string
+		conf     string
+		expected string
+	}{
+		{"empty", "", block},
+		{"appended", "set -g mouse on", "set -g mouse on\n\n" + block},
+		{"updated", "set -g mouse on\n\n" + old + "set -g base-index 1\n", "set -g mouse on\n\n" + block + "set -g base-index 1\n"},
+	}
+	for _, c := range cases {
+		if conf := withTmuxConfBlock(c.conf, block); conf != c.expected {
+			t.Errorf("%s: expected\n%q\ngot\n%q", c.name, c.expected, conf)
+		}
+	}
+}
+
+func TestWithoutTmuxConfBlock(t *testing.T) {
+	block := TmuxConfBlock(DefaultTmuxBindings())
+	if conf, found := withoutTmuxConfBlock("set -g mouse on\n\n" + block); !found || conf != "set -g mouse on\n" {
+		t.Errorf("Expected the appended block to be taken out, got %q", conf)
+	}
+	if conf, _ := withoutTmuxConfBlock("a\n" + block + "b\n"); conf != "a\nb\n" {
+		t.Errorf("Expected the lines around the block to stay, got %q", conf)
+	}
+	if _, found := withoutTmuxConfBlock("set -g mouse on\n"); found {
+		t.Errorf("Expected no block to be found")
+	}
+}
+
+func TestInstallAndRemoveTmuxConfBlock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tmux", "tmux.conf")
+	block := TmuxConfBlock(DefaultTmuxBindings())
+	if err := InstallTmuxConfBlock(path, block); err != nil {
+		t.Fatal(err)
+	}
+	// Installing twice updates the block instead of adding another.
+	if err := InstallTmuxConfBlock(path, block); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != block {
+		t.Errorf("Expected only the block, got\n%s", data)
+	}
+
+	if err := RemoveTmuxConfBlock(path); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); len(data) > 0 {
+		t.Errorf("Expected nothing left, got\n%s", data)
+	}
+	if err := RemoveTmuxConfBlock(path); !errors.Is(err, ErrNoTmuxConfBlock) {
+		t.Errorf("Expected ErrNoTmuxConfBlock, got %v", err)
+	}
+}
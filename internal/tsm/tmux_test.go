@@ -0,0 +1,179 @@
+package tsm
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSessions(t *testing.T) {
+	out := strings.Join([]string{
+		strings.Join([]string{"$0", "main", "3", "1", "1700000000", "1700000100", "", "/home/user", ""}, fieldSeparator),
+		strings.Join([]string{"$3", "work:api", "1", "0", "1700000200", "1700000300", "work", "/home/user/api", "work,go"}, fieldSeparator),
+		"",
+	}, "\n")
+
+	sessions, err := parseSessions(out)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("Expected 2 sessions, got %d", len(sessions))
+	}
+
+	expected := []Session{
+		{
+			Id:           "$0",
+			Name:         "main",
+			Windows:      3,
+			Attached:     1,
+			Created:      time.Unix(1700000000, 0),
+			LastActivity: time.Unix(1700000100, 0),
+			Group:        "",
+			Path:         "/home/user",
+		},
+		{
+			Id:           "$3",
+			Name:         "work:api",
+			Windows:      1,
+			Attached:     0,
+			Created:      time.Unix(1700000200, 0),
+			LastActivity: time.Unix(1700000300, 0),
+			Group:        "work",
+			Path:         "/home/user/api",
+			Tags:         []string{"go", "work"},
+		},
+	}
+	for i := range expected {
+		if !reflect.DeepEqual(sessions[i], expected[i]) {
+			t.Errorf("Expected session %+v, got %+v", expected[i], sessions[i])
+		}
+	}
+}
+
+func TestParseSessionsRejectsMalformedLines(t *testing.T) {
+	tests := []string{
+		"main: 3 windows (created Tue Nov 14 22:13:20 2023)",
+		strings.Join([]string{"$0", "main", "three", "1", "1700000000", "1700000100", "", "/home/user", ""}, fieldSeparator),
+		strings.Join([]string{"$0", "main", "3", "1", "yesterday", "1700000100", "", "/home/user", ""}, fieldSeparator),
+	}
+
+	for _, test := range tests {
+		if _, err := parseSessions(test); err == nil {
+			t.Errorf("Expected an error parsing %q", test)
+		}
+	}
+}
+
+func TestParseTmuxError(t *testing.T) {
+	tests := []struct {
+		stderr   string
+		expected error
+	}{
+		{"no server running on /tmp/tmux-1000/default\n", ErrNoServer},
+		{"error connecting to /tmp/tmux-1000/default (No such file or directory)\n", ErrNoServer},
+		{"error connecting to /tmp/tmux-1000/default (Permission denied)\n", ErrPermissionDenied},
+		{"can't find session: work\n", ErrSessionNotFound},
+		{"no such session: =work:\n", ErrSessionNotFound},
+		{"duplicate session: work\n", ErrDuplicateSession},
+	}
+
+	for _, test := range tests {
+		err := parseTmuxError([]string{"list-sessions"}, test.stderr, errors.New("exit status 1"))
+		if !errors.Is(err, test.expected) {
+			t.Errorf("Expected %q to be parsed as %v, got %v", test.stderr, test.expected, err)
+		}
+	}
+}
+
+func TestSocketPath(t *testing.T) {
+	t.Setenv("TMUX_TMPDIR", "/run/user")
+	t.Setenv("TMUX", "/run/user/tmux-1000/main,123,0")
+	dir := socketDir()
+	tests := []struct {
+		tmux     *Tmux
+		expected string
+	}{
+		{NewTmux("work", ""), dir + "/work"},
+		{NewTmux("", "/tmp/pairing.sock"), "/tmp/pairing.sock"},
+		{NewTmux("", ""), "/run/user/tmux-1000/main"},
+	}
+
+	for _, test := range tests {
+		if path := test.tmux.SocketPath(); path != test.expected {
+			t.Errorf("Expected socket %s, got %s", test.expected, path)
+		}
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	if quoted := shellQuote("it's"); quoted != `'it'\''s'` {
+		t.Errorf("Expected quote to be escaped, got %s", quoted)
+	}
+}
+
+// serverTmux starts a tmux server of its own with the sessions, skipping the
+// test when there is no tmux to start.
+func serverTmux(t *testing.T, sessions ...string) *Tmux {
+	t.Helper()
+	if _, err := exec.LookPath("tmux"); err != nil {
+		t.Skip("tmux is not installed")
+	}
+	// Unix socket paths are short, too short for most test temp dirs.
+	dir, err := os.MkdirTemp("", "tsmtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	socket := filepath.Join(dir, "tmux")
+	t.Cleanup(func() {
+		_ = exec.Command("tmux", "-S", socket, "kill-server").Run()
+		os.RemoveAll(dir)
+	})
+	for _, session := range sessions {
+		if out, err := exec.Command("tmux", "-S", socket, "-f", "/dev/null", "new-session", "-d", "-s", session).CombinedOutput(); err != nil {
+			t.Fatalf("Starting %s: %v %s", session, err, out)
+		}
+	}
+	return NewTmux("", socket)
+}
+
+func TestProtectedSessionIsNotKilledThroughAPrefix(t *testing.T) {
+	tmux := serverTmux(t, "production")
+	config := Config{ProtectedSessions: []string{"production"}}
+	if config.Protected("prod") {
+		t.Fatalf("Expected prod itself not to be protected")
+	}
+	if err := tmux.TmuxKillSession("prod"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Expected ErrSessionNotFound killing prod, got %v", err)
+	}
+	sessions, err := tmux.TmuxListSessions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 || sessions[0].Name != "production" {
+		t.Errorf("Expected production to be left running, got %+v", sessions)
+	}
+}
+
+func TestSessionsAreTargetedByTheirExactName(t *testing.T) {
+	// Outside of tmux a switch only checks the session is there.
+	t.Setenv("TMUX", "")
+	tmux := serverTmux(t, "production")
+	if err := tmux.TmuxSwitchSession("prod"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Expected ErrSessionNotFound switching to prod, got %v", err)
+	}
+	if err := tmux.TmuxRenameSession("prod", "staging"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Expected ErrSessionNotFound renaming prod, got %v", err)
+	}
+	if _, err := tmux.TmuxListWindows("prod"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Expected ErrSessionNotFound listing the windows of prod, got %v", err)
+	}
+	if err := tmux.TmuxSwitchSession("production"); err != nil {
+		t.Errorf("Expected to switch to production, got %v", err)
+	}
+}
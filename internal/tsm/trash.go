@@ -0,0 +1,146 @@
+package tsm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// ErrNothingToUndo is returned when the trash has no session left to bring
+// back.
+var ErrNothingToUndo = errors.New("nothing to undo")
+
+// TrashVersion is bumped whenever the trash format changes in a way older
+// versions of tsm cannot read.
+const TrashVersion = 1
+
+// Trash keeps the sessions killed from tsm for a while so they can be
+// brought back. Sessions killed together are restored together.
+type Trash struct {
+	Version int          `json:"version"`
+	Entries []TrashEntry `json:"entries"`
+}
+
+type TrashEntry struct {
+	Killed  time.Time       `json:"killed"`
+	Session SnapshotSession `json:"session"`
+}
+
+// DefaultTrashPath is where the trash lives unless told otherwise.
+func DefaultTrashPath() string {
+	return filepath.Join(stateDir(), "trash.json")
+}
+
+// LoadTrash reads the trash at path. A missing file or an empty path is an
+// empty trash.
+func LoadTrash(path string) (Trash, error) {
+	trash := Trash{Version: TrashVersion}
+	if len(path) == 0 {
+		return trash, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return trash, nil
+	}
+	if err != nil {
+		return trash, err
+	}
+	if err := json.Unmarshal(data, &trash); err != nil {
+		return trash, fmt.Errorf("%s: %w", path, err)
+	}
+	if trash.Version > TrashVersion {
+		return Trash{Version: TrashVersion}, fmt.Errorf("%s: trash version %d is newer than this tsm understands", path, trash.Version)
+	}
+	return trash, nil
+}
+
+// SaveTrash writes the trash to path, or only keeps it in memory when path
+// is empty.
+func SaveTrash(path string, trash Trash) error {
+	if len(path) == 0 {
+		return nil
+	}
+	trash.Version = TrashVersion
+	return writeJSON(path, trash)
+}
+
+// Add puts sessions killed at the same time into the trash.
+func (trash *Trash) Add(sessions []SnapshotSession, killed time.Time) {
+	for _, session := range sessions {
+		trash.Entries = append(trash.Entries, TrashEntry{Killed: killed, Session: session})
+	}
+}
+
+// Prune throws away whatever was killed longer than retention ago.
+func (trash *Trash) Prune(retention time.Duration, now time.Time) {
+	trash.Entries = slices.DeleteFunc(trash.Entries, func(e TrashEntry) bool {
+		return now.Sub(e.Killed) > retention
+	})
+}
+
+// Latest returns the entries of the most recent kill.
+func (trash Trash) Latest() []TrashEntry {
+	var latest time.Time
+	for _, entry := range trash.Entries {
+		if entry.Killed.After(latest) {
+			latest = entry.Killed
+		}
+	}
+	var entries []TrashEntry
+	for _, entry := range trash.Entries {
+		if entry.Killed.Equal(latest) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Remove takes the entries out of the trash. A session killed again later,
+// or earlier, under the same name keeps its own entry.
+func (trash *Trash) Remove(entries ...TrashEntry) {
+	trash.Entries = slices.DeleteFunc(trash.Entries, func(e TrashEntry) bool {
+		return slices.ContainsFunc(entries, func(removed TrashEntry) bool {
+			return removed.Session.Name == e.Session.Name && removed.Killed.Equal(e.Killed)
+		})
+	})
+}
+
+// undoKill brings back the sessions of the most recent kill that are still
+// within the retention window and returns their names.
+func (m *model) undoKill() ([]string, error) {
+	m.trash.Prune(m.config.TrashRetention, time.Now())
+	latest := m.trash.Latest()
+	if len(latest) == 0 {
+		return nil, ErrNothingToUndo
+	}
+
+	// A session that has been recreated by hand since is dropped from the
+	// trash, there is nothing left to undo for it. One that fails to come
+	// back stays, along with the rest, for another try.
+	var restored []string
+	var done []TrashEntry
+	var err error
+	for _, entry := range latest {
+		session := entry.Session
+		if slices.ContainsFunc(m.sessions, func(s Session) bool { return s.Name == session.Name }) {
+			err = fmt.Errorf("%s: %w", session.Name, ErrDuplicateSession)
+			done = append(done, entry)
+			continue
+		}
+		if restoreErr := RestoreSession(m.tmux, session); restoreErr != nil {
+			err = restoreErr
+			break
+		}
+		restored = append(restored, session.Name)
+		done = append(done, entry)
+	}
+	m.trash.Remove(done...)
+	if saveErr := SaveTrash(m.config.TrashPath, m.trash); err == nil {
+		err = saveErr
+	}
+	return restored, err
+}
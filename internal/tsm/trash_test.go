@@ -0,0 +1,183 @@
+package tsm
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestTrashLatestAndPrune(t *testing.T) {
+	now := time.Now()
+	var trash Trash
+	trash.Add([]SnapshotSession{{Name: "old"}}, now.Add(-2*time.Hour))
+	trash.Add([]SnapshotSession{{Name: "api"}, {Name: "web"}}, now.Add(-time.Minute))
+
+	latest := trash.Latest()
+	if len(latest) != 2 || latest[0].Session.Name != "api" || latest[1].Session.Name != "web" {
+		t.Errorf("Expected the sessions killed together last, got %v", latest)
+	}
+
+	trash.Prune(time.Hour, now)
+	if len(trash.Entries) != 2 {
+		t.Errorf("Expected the expired session to be pruned, got %v", trash.Entries)
+	}
+
+	trash.Remove(trash.Latest()...)
+	if len(trash.Latest()) != 0 {
+		t.Errorf("Expected an empty trash, got %v", trash.Entries)
+	}
+}
+
+func TestTrashRemoveKeepsEarlierKillsOfTheSameName(t *testing.T) {
+	now := time.Now()
+	var trash Trash
+	trash.Add([]SnapshotSession{{Name: "api"}}, now.Add(-time.Hour))
+	trash.Add([]SnapshotSession{{Name: "api"}}, now)
+
+	trash.Remove(trash.Latest()...)
+	if len(trash.Entries) != 1 || !trash.Entries[0].Killed.Equal(now.Add(-time.Hour)) {
+		t.Errorf("Expected the earlier api to stay in the trash, got %v", trash.Entries)
+	}
+}
+
+// restoreFailingTmux fails to create one session, so restoring it fails.
+type restoreFailingTmux struct {
+	*MockTmux
+	failing string
+}
+
+func (tmux *restoreFailingTmux) TmuxCreateSession(opts CreateSessionOptions) error {
+	if opts.Name == tmux.failing {
+		return fmt.Errorf("%s: %w", opts.Name, ErrPermissionDenied)
+	}
+	return tmux.MockTmux.TmuxCreateSession(opts)
+}
+
+func TestUndoKillKeepsSessionsThatFailToRestore(t *testing.T) {
+	now := time.Now()
+	tmux := &restoreFailingTmux{
+		MockTmux: &MockTmux{
+			sessions:    testSessions("main"),
+			windows:     map[string][]Window{"main": testWindows("editor")},
+			panes:       map[string][]Pane{},
+			environment: map[string]string{},
+			layouts:     map[string]string{},
+		},
+		failing: "web",
+	}
+	test_model := InitialSessionModel(tmux, Config{TrashRetention: time.Hour})
+	test_model.trash.Add([]SnapshotSession{{Name: "api"}}, now.Add(-time.Minute))
+	window := []SnapshotWindow{{Name: "shell", Panes: []SnapshotPane{{}}}}
+	test_model.trash.Add([]SnapshotSession{{Name: "api", Windows: window}, {Name: "web", Windows: window}}, now)
+
+	updModel, _ := sendKeys(test_model, "u")
+	test_model = updModel.(model)
+	if test_model.err == nil {
+		t.Errorf("Expected the failed restore to be reported")
+	}
+	if names := sessionNames(tmux.sessions); !slices.Equal(names, []string{"main", "api"}) {
+		t.Errorf("Expected api to be restored, got %v", names)
+	}
+	var left []string
+	for _, entry := range test_model.trash.Entries {
+		left = append(left, entry.Session.Name)
+	}
+	if !slices.Equal(left, []string{"api", "web"}) {
+		t.Errorf("Expected the earlier api and the failed web to stay in the trash, got %v", left)
+	}
+}
+
+func TestUndoKill(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trash.json")
+	tmux := &MockTmux{
+		sessions:    testSessions("main", "scratch"),
+		windows:     map[string][]Window{"main": testWindows("editor"), "scratch": testWindows("shell", "logs")},
+		panes:       map[string][]Pane{"@shell": {{Id: "%1", Command: "zsh", Path: "/tmp"}}, "@logs": {{Id: "%2", Command: "tail", Path: "/var/log"}}},
+		environment: map[string]string{},
+		layouts:     map[string]string{},
+	}
+	test_model := InitialSessionModel(tmux, Config{TrashPath: path, TrashRetention: time.Hour})
+
+	updModel, _ := sendKeys(test_model, "j", "d", "y")
+	if names := sessionNames(tmux.sessions); !slices.Equal(names, []string{"main"}) {
+		t.Fatalf("Expected scratch to be killed, got %v", names)
+	}
+	trash, err := LoadTrash(path)
+	if err != nil || len(trash.Entries) != 1 {
+		t.Fatalf("Expected scratch to be in the trash on disk, got %v, %v", trash, err)
+	}
+
+	updModel, _ = sendKeys(updModel, "u")
+	test_model = updModel.(model)
+	if test_model.err != nil {
+		t.Fatalf("Expected no error, got %v", test_model.err)
+	}
+	if names := windowNames(tmux.windows["scratch"]); !slices.Equal(names, []string{"shell", "logs"}) {
+		t.Errorf("Expected scratch to come back with its windows, got %v", names)
+	}
+	if test_model.choices[test_model.cursor].name() != "scratch" {
+		t.Errorf("Expected cursor on the restored session, got %s", test_model.choices[test_model.cursor].name())
+	}
+
+	updModel, _ = sendKeys(test_model, "u")
+	if err := updModel.(model).err; !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("Expected nothing left to undo, got %v", err)
+	}
+}
+
+func TestKillKeepsTrashOnDisk(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trash.json")
+	var earlier Trash
+	earlier.Add([]SnapshotSession{{Name: "old"}}, time.Now().Add(-time.Minute))
+	if err := SaveTrash(path, earlier); err != nil {
+		t.Fatal(err)
+	}
+	tmux := &MockTmux{
+		sessions:    testSessions("main", "scratch"),
+		windows:     map[string][]Window{"main": testWindows("editor"), "scratch": testWindows("shell")},
+		panes:       map[string][]Pane{"@shell": {{Id: "%1", Command: "zsh", Path: "/tmp"}}},
+		environment: map[string]string{},
+		layouts:     map[string]string{},
+	}
+
+	// The kill comes before anything Init started could have finished.
+	sendKeys(InitialSessionModel(tmux, Config{TrashPath: path, TrashRetention: time.Hour}), "j", "d", "y")
+	trash, err := LoadTrash(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range trash.Entries {
+		names = append(names, entry.Session.Name)
+	}
+	if !slices.Equal(names, []string{"old", "scratch"}) {
+		t.Errorf("Expected the earlier kill to stay in the trash, got %v", names)
+	}
+}
+
+func TestUndoKillHonoursRetention(t *testing.T) {
+	test_model := InitialSessionModel(&MockTmux{}, Config{TrashRetention: time.Minute})
+	test_model.trash.Add([]SnapshotSession{{Name: "old"}}, time.Now().Add(-time.Hour))
+
+	updModel, _ := sendKeys(test_model, "u")
+	if err := updModel.(model).err; !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("Expected an expired kill not to be undone, got %v", err)
+	}
+}
+
+func TestLoadTrashRefusesNewerVersions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trash.json")
+	if err := os.WriteFile(path, []byte(`{"version": 99, "entries": []}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTrash(path); err == nil {
+		t.Fatalf("Expected an error for a newer trash")
+	}
+	if m := InitialSessionModel(&MockTmux{}, Config{TrashPath: path}); m.err == nil || len(m.config.TrashPath) > 0 {
+		t.Errorf("Expected the newer trash to be reported and left alone, got %v", m.err)
+	}
+}
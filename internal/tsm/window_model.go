@@ -0,0 +1,373 @@
+package tsm
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss/list"
+)
+
+const (
+	NEW_WINDOW_INPUT Input = iota
+	RENAME_WINDOW_INPUT
+	MOVE_WINDOW_INPUT
+)
+
+type windowKeyMap struct {
+	CursorUp   key.Binding
+	CursorDown key.Binding
+	Delete     key.Binding
+	Enter      key.Binding
+	Create     key.Binding
+	Rename     key.Binding
+	SwapUp     key.Binding
+	SwapDown   key.Binding
+	Move       key.Binding
+	Panes      key.Binding
+	Back       key.Binding
+	Quit       key.Binding
+	Help       key.Binding
+}
+
+func (km windowKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{km.CursorUp, km.CursorDown, km.Create, km.Delete, km.Enter, km.Rename},
+		{km.SwapUp, km.SwapDown, km.Move, km.Panes, km.Back, km.Quit},
+	}
+}
+
+func (km windowKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{
+		km.Back,
+		km.Help,
+	}
+}
+
+var default_window_keys = windowKeyMap{
+	CursorUp: key.NewBinding(
+		key.WithKeys("k", "ctrl+p"),
+		key.WithHelp("ctrl+p/k", "move up"),
+	),
+	CursorDown: key.NewBinding(
+		key.WithKeys("j", "ctrl+n"),
+		key.WithHelp("ctrl+n/j", "move down"),
+	),
+	Delete: key.NewBinding(
+		key.WithKeys("d"),
+		key.WithHelp("d", "kill window"),
+	),
+	Enter: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "select window"),
+	),
+	Create: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "create window"),
+	),
+	Rename: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "rename window"),
+	),
+	SwapUp: key.NewBinding(
+		key.WithKeys("K"),
+		key.WithHelp("K", "swap up"),
+	),
+	SwapDown: key.NewBinding(
+		key.WithKeys("J"),
+		key.WithHelp("J", "swap down"),
+	),
+	Move: key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "move to index"),
+	),
+	Panes: key.NewBinding(
+		key.WithKeys("l", "right"),
+		key.WithHelp("l/→", "panes"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("h", "left", "esc"),
+		key.WithHelp("h/esc", "back to sessions"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("q", "ctrl+c"),
+		key.WithHelp("ctrl+c/q", "quit"),
+	),
+	Help: key.NewBinding(
+		key.WithKeys("?"),
+		key.WithHelp("?", "show help"),
+	),
+}
+
+type windowModel struct {
+	session Session
+	windows []Window
+	cursor  int
+	state   State
+	inputs  []textinput.Model
+	focused Input
+	help    help.Model
+	keyMap  windowKeyMap
+	tmux    Tmuxer
+	styles  Styles
+	err     error
+}
+
+func InitialWindowModel(tmux Tmuxer, session Session, styles Styles) windowModel {
+	inputs := make([]textinput.Model, 3)
+	inputs[NEW_WINDOW_INPUT] = createSessionInputBubble("New window name")
+	inputs[RENAME_WINDOW_INPUT] = createSessionInputBubble("Rename window")
+	inputs[MOVE_WINDOW_INPUT] = createSessionInputBubble("Target index")
+
+	windows, err := tmux.TmuxListWindows(session.Name)
+	help := newHelp(styles)
+
+	m := windowModel{
+		session: session,
+		windows: windows,
+		state:   MANAGE_STATE,
+		inputs:  inputs,
+		help:    help,
+		keyMap:  default_window_keys,
+		tmux:    tmux,
+		styles:  styles,
+		err:     err,
+	}
+	for i, window := range windows {
+		if window.Active {
+			m.cursor = i
+		}
+	}
+
+	return m
+}
+
+func (m windowModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m windowModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if _, ok := msg.(refreshMsg); ok {
+		m.reload()
+		return m, nil
+	}
+
+	switch m.state {
+	case MANAGE_STATE:
+		return m.updateManageState(msg)
+	case CREATE_STATE, RENAME_STATE, MOVE_STATE:
+		return m.updateInputState(msg)
+	}
+
+	return m, nil
+}
+
+// reload fetches the window list again and keeps the cursor in bounds.
+func (m *windowModel) reload() {
+	m.windows, m.err = m.tmux.TmuxListWindows(m.session.Name)
+	m.cursor = min(m.cursor, max(len(m.windows)-1, 0))
+}
+
+func (m windowModel) updateManageState(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if closeHelp(&m.help, msg) {
+		return m, nil
+	}
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		m.err = nil
+		switch {
+		case key.Matches(msg, m.keyMap.CursorUp):
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case key.Matches(msg, m.keyMap.CursorDown):
+			if m.cursor < len(m.windows)-1 {
+				m.cursor++
+			}
+		case key.Matches(msg, m.keyMap.Delete):
+			if len(m.windows) == 0 {
+				break
+			}
+			m.err = m.tmux.TmuxKillWindow(m.windows[m.cursor].Id)
+			if m.err == nil {
+				m.reload()
+				// Killing the last window takes the session down with it.
+				if len(m.windows) == 0 {
+					return m, back
+				}
+			}
+		case key.Matches(msg, m.keyMap.Enter):
+			if len(m.windows) == 0 {
+				break
+			}
+			m.err = m.tmux.TmuxSelectWindow(m.windows[m.cursor].Id)
+			if m.err == nil {
+				m.err = m.tmux.TmuxSwitchSession(m.session.Name)
+			}
+			if m.err == nil {
+				return m, tea.Quit
+			}
+		case key.Matches(msg, m.keyMap.Create):
+			m.state = CREATE_STATE
+			m.focused = NEW_WINDOW_INPUT
+		case key.Matches(msg, m.keyMap.Rename):
+			if len(m.windows) == 0 {
+				break
+			}
+			m.state = RENAME_STATE
+			m.focused = RENAME_WINDOW_INPUT
+		case key.Matches(msg, m.keyMap.Move):
+			if len(m.windows) == 0 {
+				break
+			}
+			m.state = MOVE_STATE
+			m.focused = MOVE_WINDOW_INPUT
+		case key.Matches(msg, m.keyMap.SwapUp):
+			if m.cursor == 0 {
+				break
+			}
+			m.err = m.tmux.TmuxSwapWindow(m.windows[m.cursor].Id, m.windows[m.cursor-1].Id)
+			if m.err == nil {
+				m.cursor--
+				m.reload()
+			}
+		case key.Matches(msg, m.keyMap.SwapDown):
+			if m.cursor >= len(m.windows)-1 {
+				break
+			}
+			m.err = m.tmux.TmuxSwapWindow(m.windows[m.cursor].Id, m.windows[m.cursor+1].Id)
+			if m.err == nil {
+				m.cursor++
+				m.reload()
+			}
+		case key.Matches(msg, m.keyMap.Panes):
+			if len(m.windows) == 0 {
+				break
+			}
+			return m, openPanes(m.session, m.windows[m.cursor])
+		case key.Matches(msg, m.keyMap.Back):
+			return m, back
+		case key.Matches(msg, m.keyMap.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, m.keyMap.Help):
+			m.help.ShowAll = true
+		}
+	}
+
+	return m, nil
+}
+
+func (m windowModel) updateInputState(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	m.inputs[m.focused].Focus()
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, default_form_keys.Cancel):
+			m.state = MANAGE_STATE
+			m.inputs[m.focused].Reset()
+			return m, nil
+		case key.Matches(msg, default_form_keys.Submit):
+			value := m.inputs[m.focused].Value()
+			switch m.focused {
+			case NEW_WINDOW_INPUT:
+				_, m.err = m.tmux.TmuxCreateWindow(m.session.Name, value, "")
+			case RENAME_WINDOW_INPUT:
+				m.err = m.tmux.TmuxRenameWindow(m.windows[m.cursor].Id, value)
+			case MOVE_WINDOW_INPUT:
+				var index int
+				index, m.err = strconv.Atoi(value)
+				if m.err == nil {
+					m.err = m.tmux.TmuxMoveWindow(m.windows[m.cursor].Id, m.session.Name, index)
+				}
+			}
+			if m.err == nil {
+				m.state = MANAGE_STATE
+				m.inputs[m.focused].Reset()
+				m.reload()
+				return m, nil
+			}
+		}
+	}
+
+	m.inputs[m.focused], cmd = m.inputs[m.focused].Update(msg)
+	return m, cmd
+}
+
+func (m windowModel) viewManageState() string {
+	windows := list.New()
+	for i, window := range m.windows {
+		cursor := " "
+		if i == m.cursor {
+			cursor = ">"
+			windows.Item(m.styles.selected.Render(fmt.Sprintf("%s %s", cursor, renderWindow(window))))
+		} else {
+			windows.Item(fmt.Sprintf("%s %s", cursor, renderWindow(window)))
+		}
+	}
+	windows = windows.Enumerator(blankEnumerator)
+
+	layout := ""
+	if len(m.windows) > 0 {
+		layout = "\n" + m.styles.help.Render("layout: "+m.windows[m.cursor].Layout)
+	}
+
+	return fmt.Sprintf(
+		"%s\n%s",
+		m.styles.root.Render(
+			fmt.Sprintf(
+				"%s\n%s%s%s",
+				m.styles.header.Render(fmt.Sprintf("Windows of %s:", m.session.Name)),
+				m.styles.list.Render(windows.String()),
+				layout,
+				m.styles.renderStatus(m.err),
+			),
+		),
+		m.help.View(m.keyMap),
+	)
+}
+
+func (m windowModel) viewInputState() string {
+	var actionString string
+	switch m.focused {
+	case NEW_WINDOW_INPUT:
+		actionString = "Create window:"
+	case RENAME_WINDOW_INPUT:
+		actionString = "Rename window:"
+	case MOVE_WINDOW_INPUT:
+		actionString = "Move window to index:"
+	}
+
+	return m.styles.root.Render(
+		fmt.Sprintf(
+			"%s\n%s%s",
+			m.styles.header.Render(actionString),
+			m.inputs[m.focused].View(),
+			m.styles.renderStatus(m.err),
+		),
+	)
+}
+
+func (m windowModel) View() string {
+	if m.help.ShowAll {
+		return m.styles.viewKeyOverlay(m.keyMap)
+	}
+	switch m.state {
+	case CREATE_STATE, RENAME_STATE, MOVE_STATE:
+		return m.viewInputState()
+	default:
+		return m.viewManageState()
+	}
+}
+
+func renderWindow(window Window) string {
+	active := ""
+	if window.Active {
+		active = " *"
+	}
+	return fmt.Sprintf("%d: %s%s (%d panes)", window.Index, window.Name, active, window.Panes)
+}
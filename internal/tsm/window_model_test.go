@@ -0,0 +1,142 @@
+package tsm
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func testWindows(names ...string) []Window {
+	windows := make([]Window, len(names))
+	for i, name := range names {
+		windows[i] = Window{Id: "@" + name, Index: i, Name: name, Active: i == 0, Panes: 1}
+	}
+	return windows
+}
+
+func newTestWindowModel() windowModel {
+	tmux := &MockTmux{
+		sessions: testSessions("test_session_1"),
+		windows:  map[string][]Window{"test_session_1": testWindows("editor", "shell", "logs")},
+	}
+	return InitialWindowModel(tmux, tmux.sessions[0], configStyles(Config{}))
+}
+
+func sendKeys(m tea.Model, keys ...string) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	for _, k := range keys {
+		var msg tea.Key
+		switch k {
+		case "enter":
+			msg = tea.Key{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.Key{Type: tea.KeyEsc}
+		case "tab":
+			msg = tea.Key{Type: tea.KeyTab}
+		case "shift+tab":
+			msg = tea.Key{Type: tea.KeyShiftTab}
+		default:
+			runes, alt := strings.CutPrefix(k, "alt+")
+			msg = tea.Key{Type: tea.KeyRunes, Runes: []rune(runes), Alt: alt}
+		}
+		m, cmd = m.Update(tea.KeyMsg(msg))
+	}
+	return m, cmd
+}
+
+func windowNames(windows []Window) []string {
+	names := make([]string, len(windows))
+	for i, window := range windows {
+		names[i] = window.Name
+	}
+	return names
+}
+
+func TestWindowSwapFollowsCursor(t *testing.T) {
+	tests := []struct {
+		keys            []string
+		expected_cursor int
+		expected_names  []string
+	}{
+		{[]string{"J"}, 1, []string{"shell", "editor", "logs"}},
+		{[]string{"J", "J"}, 2, []string{"shell", "logs", "editor"}},
+		{[]string{"J", "J", "J"}, 2, []string{"shell", "logs", "editor"}},
+		{[]string{"K"}, 0, []string{"editor", "shell", "logs"}},
+		{[]string{"j", "j", "K"}, 1, []string{"editor", "logs", "shell"}},
+	}
+
+	for _, test := range tests {
+		updModel, _ := sendKeys(newTestWindowModel(), test.keys...)
+		test_model := updModel.(windowModel)
+		if test_model.cursor != test.expected_cursor {
+			t.Errorf("Expected cursor to be %d, got %d", test.expected_cursor, test_model.cursor)
+		}
+		if !slices.Equal(windowNames(test_model.windows), test.expected_names) {
+			t.Errorf("Expected windows %v, got %v", test.expected_names, windowNames(test_model.windows))
+		}
+	}
+}
+
+func TestWindowCreateRenameAndKill(t *testing.T) {
+	updModel, _ := sendKeys(newTestWindowModel(), "c", "t", "e", "s", "t", "enter")
+	test_model := updModel.(windowModel)
+	if test_model.state != MANAGE_STATE {
+		t.Fatalf("Expected state to be %d, got %d", MANAGE_STATE, test_model.state)
+	}
+	if !slices.Equal(windowNames(test_model.windows), []string{"editor", "shell", "logs", "test"}) {
+		t.Fatalf("Expected new window to be appended, got %v", windowNames(test_model.windows))
+	}
+
+	updModel, _ = sendKeys(test_model, "j", "r", "s", "h", "enter")
+	test_model = updModel.(windowModel)
+	if test_model.windows[1].Name != "sh" {
+		t.Fatalf("Expected window to be renamed to sh, got %s", test_model.windows[1].Name)
+	}
+
+	updModel, _ = sendKeys(test_model, "d")
+	test_model = updModel.(windowModel)
+	if !slices.Equal(windowNames(test_model.windows), []string{"editor", "logs", "test"}) {
+		t.Fatalf("Expected window to be killed, got %v", windowNames(test_model.windows))
+	}
+}
+
+func TestWindowBackAndSelect(t *testing.T) {
+	_, cmd := sendKeys(newTestWindowModel(), "h")
+	if _, ok := cmd().(backMsg); !ok {
+		t.Errorf("Expected cmd to be back, got %v", cmd())
+	}
+
+	updModel, cmd := sendKeys(newTestWindowModel(), "j", "enter")
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Errorf("Expected cmd to be tea.Quit, got %v", cmd())
+	}
+	mockTmux := updModel.(windowModel).tmux.(*MockTmux)
+	if mockTmux.active_session != "test_session_1" {
+		t.Errorf("Expected active session to be test_session_1, got %s", mockTmux.active_session)
+	}
+	if !mockTmux.windows["test_session_1"][1].Active {
+		t.Errorf("Expected second window to be active")
+	}
+}
+
+func TestRootModelNavigatesBetweenSessionsAndWindows(t *testing.T) {
+	tmux := &MockTmux{
+		sessions: testSessions("test_session_1"),
+		windows:  map[string][]Window{"test_session_1": testWindows("editor")},
+	}
+	var root tea.Model = InitialRootModel(tmux, DefaultConfig())
+
+	root, cmd := sendKeys(root, "l")
+	root, _ = root.Update(cmd())
+	if root.(rootModel).state != WINDOW_MANAGEMENT {
+		t.Fatalf("Expected state to be %d, got %d", WINDOW_MANAGEMENT, root.(rootModel).state)
+	}
+
+	root, cmd = sendKeys(root, "esc")
+	root, _ = root.Update(cmd())
+	if root.(rootModel).state != SESSION_MANAGEMENT {
+		t.Fatalf("Expected state to be %d, got %d", SESSION_MANAGEMENT, root.(rootModel).state)
+	}
+}